@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// AddrFlags represents the IFA_F_* flags of an interface address, as shown
+// by `ip addr`.
+type AddrFlags uint32
+
+const (
+	// AddrTemporary marks IPv6 privacy addresses (RFC 8981). The kernel uses
+	// the same bit to mark secondary IPv4 addresses, which is not reported.
+	AddrTemporary      AddrFlags = 0x001
+	AddrNoDAD          AddrFlags = 0x002
+	AddrOptimistic     AddrFlags = 0x004
+	AddrDADFailed      AddrFlags = 0x008
+	AddrHome           AddrFlags = 0x010
+	AddrDeprecated     AddrFlags = 0x020
+	AddrTentative      AddrFlags = 0x040
+	AddrPermanent      AddrFlags = 0x080
+	AddrManageTempAddr AddrFlags = 0x100
+	AddrNoPrefixRoute  AddrFlags = 0x200
+	AddrMCAutoJoin     AddrFlags = 0x400
+	AddrStablePrivacy  AddrFlags = 0x800
+)
+
+var addrFlagNames = []struct {
+	flag AddrFlags
+	name string
+}{
+	{AddrTemporary, "temporary"},
+	{AddrNoDAD, "nodad"},
+	{AddrOptimistic, "optimistic"},
+	{AddrDADFailed, "dadfailed"},
+	{AddrHome, "home"},
+	{AddrDeprecated, "deprecated"},
+	{AddrTentative, "tentative"},
+	{AddrPermanent, "permanent"},
+	{AddrManageTempAddr, "mngtmpaddr"},
+	{AddrNoPrefixRoute, "noprefixroute"},
+	{AddrMCAutoJoin, "autojoin"},
+	{AddrStablePrivacy, "stable-privacy"},
+}
+
+// String returns the names of the flags set, as printed by `ip addr`, such
+// as "temporary,deprecated".
+func (f AddrFlags) String() string {
+	var names []string
+	for _, v := range addrFlagNames {
+		if f&v.flag != 0 {
+			names = append(names, v.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// InterfaceAddr represents an address assigned to an interface.
+type InterfaceAddr struct {
+	Netif  string
+	Prefix netip.Prefix
+	// Flags holds the flags of the address. They are only reported on
+	// Linux, and are zero when they can't be read.
+	Flags AddrFlags
+}
+
+// FindDefaultAddrs returns the addresses of all interfaces using a default
+// gateway, along with their flags. Addresses can be left out through
+// ExcludeAddrFlags and ExcludeLinkLocal.
+func FindDefaultAddrs(opts ...DefaultsOption) ([]InterfaceAddr, error) {
+	return systemResolver().FindDefaultAddrs(opts...)
+}
+
+// FindDefaultAddrs is like the package-level FindDefaultAddrs, using routes
+// and addresses provided by the resolver.
+func (res *Resolver) FindDefaultAddrs(opts ...DefaultsOption) ([]InterfaceAddr, error) {
+	interfaces, err := res.FindDefaultInterfaces(opts...)
+	if err != nil {
+		return nil, err
+	}
+	o := newDefaultsOptions(opts)
+
+	var out []InterfaceAddr
+	for _, name := range interfaces {
+		addrs, err := res.addrs(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			if o.acceptsAddr(a) {
+				out = append(out, a)
+			}
+		}
+	}
+	return out, nil
+}
+
+// withAddrFlags returns addresses of the named interface with the flags
+// found in flags, which may be nil.
+func withAddrFlags(name string, prefixes []netip.Prefix, flags map[netip.Addr]AddrFlags) []InterfaceAddr {
+	out := make([]InterfaceAddr, len(prefixes))
+	for i, p := range prefixes {
+		out[i] = InterfaceAddr{Netif: name, Prefix: p, Flags: flags[p.Addr()]}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"syscall"
+)
+
+const ifaFlags = 8
+
+// addrFlags returns the flags of addresses of the interface with the
+// provided index and name, read through rtnetlink, or from
+// /proc/net/if_inet6 for IPv6 addresses when rtnetlink is unavailable. nil
+// is returned when neither can be read.
+func addrFlags(index int, name string) map[netip.Addr]AddrFlags {
+	if flags, err := netlinkAddrFlags(index); err == nil {
+		return flags
+	}
+	data, err := os.ReadFile("/proc/net/if_inet6")
+	if err != nil {
+		return nil
+	}
+	v6, err := parseProcIfInet6(string(data))
+	if err != nil {
+		return nil
+	}
+	flags := map[netip.Addr]AddrFlags{}
+	for _, a := range v6[name] {
+		flags[a.Prefix.Addr()] = a.Flags
+	}
+	return flags
+}
+
+func netlinkAddrFlags(index int) (map[netip.Addr]AddrFlags, error) {
+	msgs, err := netlinkDump(syscall.RTM_GETADDR, make([]byte, syscall.SizeofIfAddrmsg))
+	if err != nil {
+		return nil, err
+	}
+	flags := map[netip.Addr]AddrFlags{}
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWADDR {
+			continue
+		}
+		if addr, f, ok := parseNetlinkAddrFlags(m.Data, index); ok {
+			flags[addr] = f
+		}
+	}
+	return flags, nil
+}
+
+/* ifaddrmsg:
++--------+-----------+-------+-------+-------+
+| family | prefixlen | flags | scope | index |
++--------+-----------+-------+-------+-------+
+    u8        u8        u8      u8     u32
+*/
+
+// parseNetlinkAddrFlags returns the address described by b, along with its
+// flags, if it belongs to the interface with the provided index.
+func parseNetlinkAddrFlags(b []byte, index int) (netip.Addr, AddrFlags, bool) {
+	if len(b) < syscall.SizeofIfAddrmsg || int(binary.NativeEndian.Uint32(b[4:8])) != index {
+		return netip.Addr{}, 0, false
+	}
+	attrs := parseNetlinkAttrs(b[syscall.SizeofIfAddrmsg:])
+	// IFA_ADDRESS holds the peer of point-to-point IPv4 addresses, and
+	// IFA_LOCAL, which is absent for IPv6, the local one.
+	v := attrs.get(syscall.IFA_LOCAL)
+	if v == nil {
+		v = attrs.get(syscall.IFA_ADDRESS)
+	}
+	addr, ok := netip.AddrFromSlice(v)
+	if !ok {
+		return netip.Addr{}, 0, false
+	}
+
+	// ifa_flags only holds the lowest 8 bits of IFA_FLAGS.
+	flags := AddrFlags(b[2])
+	if f, ok := attrs.uint32(ifaFlags); ok {
+		flags = AddrFlags(f)
+	}
+	if b[0] == syscall.AF_INET {
+		// The bit marks secondary addresses instead.
+		flags &^= AddrTemporary
+	}
+	return addr, flags, true
+}
@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceAddrFlags(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"link", "add", "veth0", "type", "veth", "peer", "name", "veth1"},
+		{"link", "set", "veth0", "up"},
+		{"addr", "add", "10.0.0.2/24", "dev", "veth0"},
+		{"addr", "add", "10.0.0.3/24", "dev", "veth0"},
+		{"-6", "addr", "add", "2001:db8::10/64", "dev", "veth0", "nodad", "preferred_lft", "0"},
+		// Lacking a carrier, duplicate address detection doesn't
+		// complete.
+		{"-6", "addr", "add", "2001:db8::11/64", "dev", "veth0"},
+	})
+
+	var addrs []InterfaceAddr
+	require.NoError(t, ns.Do(func() (err error) {
+		addrs, err = interfaceAddrs("veth0")
+		return err
+	}))
+
+	flags := map[netip.Addr]AddrFlags{}
+	for _, a := range addrs {
+		flags[a.Prefix.Addr()] = a.Flags
+	}
+	assert.Equal(t, map[netip.Addr]AddrFlags{
+		netip.MustParseAddr("10.0.0.2"):     AddrPermanent,
+		netip.MustParseAddr("10.0.0.3"):     AddrPermanent,
+		netip.MustParseAddr("2001:db8::10"): AddrPermanent | AddrNoDAD | AddrDeprecated,
+		netip.MustParseAddr("2001:db8::11"): AddrPermanent | AddrTentative,
+	}, flags)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package gateway
+
+import "net/netip"
+
+// addrFlags returns the flags of addresses of an interface. Address flags
+// are only reported on Linux.
+func addrFlags(int, string) map[netip.Addr]AddrFlags {
+	return nil
+}
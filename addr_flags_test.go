@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDefaultAddrs(t *testing.T) {
+	res, err := NewResolver(WithRoot(procRoot(t, "1", map[string]string{
+		"route":      "linuxipv4",
+		"ipv6_route": "linuxipv6",
+		"dev":        "procNetDev",
+		"if_inet6":   "procIfInet6Flags",
+		"fib_trie":   "fibTrieMerged",
+	})))
+	require.NoError(t, err)
+
+	addr := func(prefix string, flags AddrFlags) InterfaceAddr {
+		return InterfaceAddr{Netif: "wlp4s0", Prefix: netip.MustParsePrefix(prefix), Flags: flags}
+	}
+	addrs, err := res.FindDefaultAddrs()
+	require.NoError(t, err)
+	assert.Equal(t, []InterfaceAddr{
+		addr("192.168.8.105/24", 0),
+		addr("2001:db8::42/64", AddrPermanent),
+		addr("2001:db8::a1b2:c3d4:e5f6:718/64", AddrTemporary),
+		addr("2001:db8::10/64", AddrPermanent|AddrDeprecated|AddrNoDAD),
+		addr("2001:db8::11/64", AddrPermanent|AddrTentative),
+		addr("2001:db8::13/64", AddrPermanent|AddrTentative|AddrDADFailed),
+		addr("fe80::21a:2bff:fe3c:4d5e/64", AddrPermanent),
+	}, addrs)
+
+	ips, err := res.FindDefaultIPs(
+		ExcludeAddrFlags(AddrTemporary|AddrDeprecated),
+		ExcludeAddrFlags(AddrTentative|AddrDADFailed),
+		ExcludeLinkLocal())
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.168.8.105"),
+		netip.MustParseAddr("2001:db8::42%wlp4s0"),
+	}, ips)
+}
+
+func TestAddrFlags(t *testing.T) {
+	assert.Equal(t, "", AddrFlags(0).String())
+	assert.Equal(t, "nodad,deprecated,permanent", (AddrPermanent | AddrDeprecated | AddrNoDAD).String())
+}
@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// commandDirs lists directories searched first for external tools such as
+// netstat and ip, so that a tampered environment can't substitute tools
+// installed there. PATH is only searched for tools missing from all of them,
+// such as on NixOS or with Homebrew.
+var commandDirs = []string{"/sbin", "/usr/sbin", "/bin", "/usr/bin", "/system/bin"}
+
+// commandTimeout bounds the execution of external tools when the provided
+// context has no deadline.
+const commandTimeout = 10 * time.Second
+
+// maxCommandOutput bounds the output read from external tools.
+var maxCommandOutput = 16 << 20
+
+// lookCommand returns the path of the named tool within commandDirs, or
+// within PATH otherwise. Relative entries of PATH are ignored.
+func lookCommand(name string) (string, error) {
+	for _, dir := range commandDirs {
+		p := filepath.Join(dir, name)
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() && fi.Mode()&0o111 != 0 {
+			return p, nil
+		}
+	}
+	if p, err := exec.LookPath(name); err == nil && filepath.IsAbs(p) {
+		return p, nil
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+// runCommand runs the named tool with a minimal environment forcing the C
+// locale, returning its standard output. The tool is killed once ctx is done,
+// or after commandTimeout if ctx has no deadline.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	path, err := lookCommand(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+	}
+	// Tools exceeding the output limit are killed.
+	cmdCtx, kill := context.WithCancel(ctx)
+	defer kill()
+
+	stdout := limitedBuffer{limit: maxCommandOutput, onExceeded: kill}
+	stderr := limitedBuffer{limit: 4096}
+	cmd := exec.CommandContext(cmdCtx, path, args...)
+	cmd.Env = []string{"LC_ALL=C", "PATH=" + strings.Join(commandDirs, ":")}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if stdout.exceeded {
+		return nil, &ErrOutputTooLarge{command: name}
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("%s: %w", name, ctxErr)
+		}
+		if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// limitedBuffer is a buffer refusing writes past its limit, calling
+// onExceeded, if any, once that happens. The buffer isn't embedded, so that
+// io.Copy can't bypass the limit through bytes.Buffer.ReadFrom.
+type limitedBuffer struct {
+	buf        bytes.Buffer
+	limit      int
+	exceeded   bool
+	onExceeded func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		if !b.exceeded && b.onExceeded != nil {
+			b.onExceeded()
+		}
+		b.exceeded = true
+		n, _ := b.buf.Write(p[:max(room, 0)])
+		return n, io.ErrShortWrite
+	}
+	return b.buf.Write(p)
+}
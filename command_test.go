@@ -0,0 +1,80 @@
+//go:build !windows
+
+package gateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setCommand installs a shell script as the named tool, searched in a
+// temporary directory instead of commandDirs and PATH.
+func setCommand(t *testing.T, name, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0o755))
+	prevDirs := commandDirs
+	commandDirs = []string{filepath.Join(dir, "missing"), dir}
+	t.Setenv("PATH", "")
+	t.Cleanup(func() {
+		commandDirs = prevDirs
+	})
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("LANG", "de_DE.UTF-8")
+		setCommand(t, "tool", `echo "$LC_ALL $LANG $1"`)
+		out, err := runCommand(context.Background(), "tool", "-n")
+		require.NoError(t, err)
+		assert.Equal(t, "C  -n\n", string(out))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		setCommand(t, "tool", "true")
+		_, err := runCommand(context.Background(), "other")
+		assert.Error(t, err)
+	})
+
+	t.Run("PATH", func(t *testing.T) {
+		setCommand(t, "tool", "true")
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("#!/bin/sh\necho found"), 0o755))
+		t.Setenv("PATH", "relative:"+dir)
+		out, err := runCommand(context.Background(), "other")
+		require.NoError(t, err)
+		assert.Equal(t, "found\n", string(out))
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		setCommand(t, "tool", "echo denied >&2; exit 2")
+		_, err := runCommand(context.Background(), "tool")
+		assert.ErrorContains(t, err, "denied")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		setCommand(t, "tool", "while :; do :; done")
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := runCommand(ctx, "tool")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Output limit", func(t *testing.T) {
+		setCommand(t, "tool", "while :; do echo 0123456789abcdef0123456789abcdef; done")
+		prevMax := maxCommandOutput
+		maxCommandOutput = 4096
+		t.Cleanup(func() {
+			maxCommandOutput = prevMax
+		})
+		_, err := runCommand(context.Background(), "tool")
+		var tooLarge *ErrOutputTooLarge
+		assert.ErrorAs(t, err, &tooLarge)
+	})
+}
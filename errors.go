@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"fmt"
+	"net/netip"
 	"runtime"
 )
 
@@ -20,6 +21,50 @@ type ErrInvalidRouteFileFormat struct {
 	row string
 }
 
+// ErrNoRoute is returned by LookupRoute when no route is available for the
+// requested destination, either because no table yielded a result, or
+// because a rule or a blackhole, unreachable or prohibit route rejected the
+// flow.
+type ErrNoRoute struct {
+	dst   netip.Addr
+	rule  *Rule
+	route *NetRoute
+}
+
+// ErrVRFNotFound is returned when the requested VRF device does not exist.
+type ErrVRFNotFound struct {
+	name string
+}
+
+// ErrInterfaceNotFound is returned when the requested interface does not
+// exist.
+type ErrInterfaceNotFound struct {
+	name string
+}
+
+// ErrInvalidNamespace is returned when a Namespace has neither a path nor a
+// file descriptor, such as the zero Namespace.
+type ErrInvalidNamespace struct{}
+
+// ErrPathEscapesRoot is returned when a path looked up below the root provided
+// through WithRoot resolves to a location outside of it.
+type ErrPathEscapesRoot struct {
+	path string
+	root string
+}
+
+// ErrOutputTooLarge is returned when an external tool such as netstat or ip
+// produces more output than expected.
+type ErrOutputTooLarge struct {
+	command string
+}
+
+// ErrNoRouteSource is returned by RouteChain.Routes when every source of the
+// chain failed.
+type ErrNoRouteSource struct {
+	failures []SourceFailure
+}
+
 func (*ErrCantParse) Error() string {
 	return "can't parse route table"
 }
@@ -31,3 +76,48 @@ func (*ErrNotImplemented) Error() string {
 func (e *ErrInvalidRouteFileFormat) Error() string {
 	return fmt.Sprintf("invalid row %q in route file", e.row)
 }
+
+func (e *ErrNoRoute) Error() string {
+	if e.route != nil {
+		return fmt.Sprintf("no route to %s: %s route %s in table %d", e.dst, e.route.Type, e.route.Dst, e.route.Table)
+	}
+	if e.rule != nil {
+		return fmt.Sprintf("no route to %s: %s by rule %d", e.dst, e.rule.Action, e.rule.Priority)
+	}
+	return fmt.Sprintf("no route to %s", e.dst)
+}
+
+func (e *ErrVRFNotFound) Error() string {
+	return fmt.Sprintf("VRF %q not found", e.name)
+}
+
+func (e *ErrInterfaceNotFound) Error() string {
+	return fmt.Sprintf("interface %q not found", e.name)
+}
+
+func (*ErrInvalidNamespace) Error() string {
+	return "namespace has neither a path nor a file descriptor"
+}
+
+func (e *ErrPathEscapesRoot) Error() string {
+	return fmt.Sprintf("path %q escapes root %q", e.path, e.root)
+}
+
+func (e *ErrOutputTooLarge) Error() string {
+	return fmt.Sprintf("output of %s is too large", e.command)
+}
+
+func (e *ErrNoRouteSource) Error() string {
+	if len(e.failures) == 0 {
+		return "no route source available"
+	}
+	return "no route source succeeded: " + describeFailures(e.failures)
+}
+
+func (e *ErrNoRouteSource) Unwrap() []error {
+	errs := make([]error, len(e.failures))
+	for i, f := range e.failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
@@ -0,0 +1,209 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const routeTableLocal = 255
+
+/* fib_trie:
+Main:
+  +-- 0.0.0.0/0 3 0 5
+     |-- 0.0.0.0
+        /0 universe UNICAST
+     +-- 192.0.2.0/24 2 0 2
+        +-- 192.0.2.0/30 2 0 2
+           |-- 192.0.2.0
+              /24 link UNICAST
+           |-- 192.0.2.2
+              /32 host LOCAL
+        |-- 192.0.2.255
+           /32 link BROADCAST
+Local:
+  ...
+
+Each table starts with its name ("Main:", "Local:", or "Id <n>:" for other
+tables). Internal nodes of the trie ("+--") are followed by leaves ("|--")
+holding an address, which is followed by one line per prefix length routed
+through that address, with its scope, type, and TOS, if any. Since Linux 4.0,
+the main and local tables share a single trie unless policy routing rules
+are in use, in which case the same entries are listed under both tables.
+*/
+
+var fibTrieScopes = map[string]RouteScope{
+	"universe": RouteScopeUniverse,
+	"site":     RouteScopeSite,
+	"link":     RouteScopeLink,
+	"host":     RouteScopeHost,
+	"nowhere":  RouteScopeNowhere,
+}
+
+// ParseFibTrie parses the contents of /proc/net/fib_trie, returning an IPv4
+// route for each of its entries, including those of the local table, which
+// /proc/net/route never shows. Entries only carry their destination, type,
+// scope and table; fib_trie lists neither gateways nor interfaces.
+func ParseFibTrie(r io.Reader) (NetRouteList, error) {
+	type section struct {
+		table  uint32
+		routes NetRouteList
+	}
+	var sections []*section
+	var current *section
+	var leaf netip.Addr
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		row := scanner.Text()
+		v := strings.TrimSpace(row)
+		switch {
+		case len(v) == 0, strings.HasPrefix(v, "+--"):
+			continue
+		case strings.HasSuffix(v, ":"):
+			table, ok := fibTrieTable(strings.TrimSuffix(v, ":"))
+			if !ok {
+				return nil, &ErrInvalidRouteFileFormat{row: row}
+			}
+			current = &section{table: table}
+			sections = append(sections, current)
+			leaf = netip.Addr{}
+		case strings.HasPrefix(v, "|--"):
+			addr, err := netip.ParseAddr(strings.TrimSpace(strings.TrimPrefix(v, "|--")))
+			if err != nil || !addr.Is4() || current == nil {
+				return nil, &ErrInvalidRouteFileFormat{row: row}
+			}
+			leaf = addr
+		case strings.HasPrefix(v, "/"):
+			if !leaf.IsValid() {
+				return nil, &ErrInvalidRouteFileFormat{row: row}
+			}
+			route, ok := parseFibTrieEntry(leaf, strings.Fields(v))
+			if !ok {
+				return nil, &ErrInvalidRouteFileFormat{row: row}
+			}
+			route.Table = current.table
+			current.routes = append(current.routes, route)
+		default:
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var mainRoutes, localRoutes *section
+	for _, s := range sections {
+		switch s.table {
+		case routeTableMain:
+			mainRoutes = s
+		case routeTableLocal:
+			localRoutes = s
+		}
+	}
+	if mainRoutes != nil && localRoutes != nil && sameFibTrieEntries(mainRoutes.routes, localRoutes.routes) {
+		// Both tables share the same trie; tell entries apart by their type,
+		// the same way the kernel picks the table when adding addresses.
+		var main, local NetRouteList
+		for _, r := range mainRoutes.routes {
+			if r.Type == RouteTypeLocal || r.Type == RouteTypeBroadcast || r.Type == RouteTypeAnycast {
+				r.Table = routeTableLocal
+				local = append(local, r)
+			} else {
+				main = append(main, r)
+			}
+		}
+		mainRoutes.routes, localRoutes.routes = main, local
+	}
+
+	var routes NetRouteList
+	for _, s := range sections {
+		routes = append(routes, s.routes...)
+	}
+	return routes, nil
+}
+
+func getRoutesFibTrie(readFile func(string) ([]byte, error), source string) (NetRouteList, error) {
+	f, err := readFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFibTrie(bytes.NewReader(f))
+}
+
+func fibTrieTable(name string) (uint32, bool) {
+	switch name {
+	case "Main":
+		return routeTableMain, true
+	case "Local":
+		return routeTableLocal, true
+	}
+	id, ok := strings.CutPrefix(name, "Id ")
+	if !ok {
+		return 0, false
+	}
+	table, err := strconv.ParseUint(id, 10, 32)
+	return uint32(table), err == nil
+}
+
+// parseFibTrieEntry parses an entry such as "/24 link UNICAST tos=16". Scopes
+// and types unknown to the kernel are printed as "scope=<n>" and
+// "type <n>", respectively. TOS is ignored.
+func parseFibTrieEntry(leaf netip.Addr, fields []string) (NetRoute, bool) {
+	if len(fields) < 3 {
+		return NetRoute{}, false
+	}
+	bits, err := strconv.Atoi(strings.TrimPrefix(fields[0], "/"))
+	if err != nil {
+		return NetRoute{}, false
+	}
+	dst, err := leaf.Prefix(bits)
+	if err != nil {
+		return NetRoute{}, false
+	}
+
+	scope, ok := fibTrieScopes[fields[1]]
+	if !ok {
+		n, err := strconv.ParseUint(strings.TrimPrefix(fields[1], "scope="), 10, 8)
+		if err != nil {
+			return NetRoute{}, false
+		}
+		scope = RouteScope(n)
+	}
+
+	var typ RouteType
+	if fields[2] == "type" && len(fields) > 3 {
+		n, err := strconv.ParseUint(fields[3], 10, 8)
+		if err != nil {
+			return NetRoute{}, false
+		}
+		typ = RouteType(n)
+	} else if idx := slices.Index(routeTypeNames[:], strings.ToLower(fields[2])); idx >= 0 {
+		typ = RouteType(idx)
+	} else {
+		return NetRoute{}, false
+	}
+
+	route := NetRoute{
+		Kind:        NetRouteKindV4,
+		Type:        typ,
+		Scope:       scope,
+		Dst:         dst,
+		Destination: dst.String(),
+	}
+	if bits == 0 {
+		route.Destination = "default"
+	}
+	route.Flags = routeFlagsFor(&route).String()
+	return route, true
+}
+
+func sameFibTrieEntries(a, b NetRouteList) bool {
+	return slices.EqualFunc(a, b, func(x, y NetRoute) bool {
+		return x.Dst == y.Dst && x.Type == y.Type && x.Scope == y.Scope
+	})
+}
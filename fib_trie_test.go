@@ -0,0 +1,101 @@
+package gateway
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFibTrieFixture(t *testing.T, name string) NetRouteList {
+	t.Helper()
+	routes, err := ParseFibTrie(bytes.NewReader(fixtureFile(t, name)))
+	require.NoError(t, err)
+	return routes
+}
+
+func fibTrieEntries(routes NetRouteList, table uint32) []string {
+	var out []string
+	for _, r := range routes {
+		if r.Table == table {
+			out = append(out, r.Dst.String()+" "+r.Scope.String()+" "+r.Type.String())
+		}
+	}
+	return out
+}
+
+func TestParseFibTrie(t *testing.T) {
+	t.Run("Shared trie", func(t *testing.T) {
+		routes := parseFibTrieFixture(t, "fibTrieMerged")
+		assert.Equal(t, []string{
+			"0.0.0.0/0 global unicast",
+			"169.254.0.0/16 link unicast",
+			"172.17.0.0/16 link unicast",
+			"172.18.0.0/16 link unicast",
+			"192.168.8.0/24 link unicast",
+		}, fibTrieEntries(routes, routeTableMain))
+		assert.Equal(t, []string{
+			"127.0.0.0/8 host local",
+			"127.0.0.1/32 host local",
+			"127.255.255.255/32 link broadcast",
+			"172.17.0.1/32 host local",
+			"172.17.255.255/32 link broadcast",
+			"172.18.0.1/32 host local",
+			"172.18.255.255/32 link broadcast",
+			"192.168.8.105/32 host local",
+			"192.168.8.255/32 link broadcast",
+		}, fibTrieEntries(routes, routeTableLocal))
+		assert.Equal(t, "default", routes[0].Destination)
+		assert.True(t, routes[0].Type.Forwards())
+	})
+
+	t.Run("Policy routing", func(t *testing.T) {
+		routes := parseFibTrieFixture(t, "fibTrieRules")
+		assert.Equal(t, []string{
+			"0.0.0.0/0 global unicast",
+			"192.0.2.0/24 global unreachable",
+			"192.0.2.64/26 global throw",
+			"192.0.2.128/25 global prohibit",
+		}, fibTrieEntries(routes, 100))
+		assert.Equal(t, []string{
+			"0.0.0.0/0 global unicast",
+			"10.0.0.0/24 link unicast",
+			"172.16.0.0/16 link unicast",
+			"198.51.100.0/24 global unicast",
+			"203.0.113.0/24 global blackhole",
+		}, fibTrieEntries(routes, routeTableMain))
+		assert.Len(t, fibTrieEntries(routes, routeTableLocal), 8)
+	})
+
+	t.Run("Legacy kernel", func(t *testing.T) {
+		routes := parseFibTrieFixture(t, "fibTrieLegacy")
+		assert.Equal(t, []string{
+			"10.0.2.0/32 link broadcast",
+			"10.0.2.15/32 host local",
+			"10.0.2.255/32 link broadcast",
+			"127.0.0.0/32 link broadcast",
+			"127.0.0.0/8 host local",
+			"127.0.0.1/32 host local",
+			"127.255.255.255/32 link broadcast",
+		}, fibTrieEntries(routes, routeTableLocal))
+		assert.Len(t, fibTrieEntries(routes, routeTableMain), 3)
+		assert.Equal(t, "UH", routes[1].Flags)
+	})
+
+	t.Run("Unknown scope and type", func(t *testing.T) {
+		routes, err := ParseFibTrie(bytes.NewBufferString("Id 7:\n  |-- 10.0.0.0\n     /8 scope=12 type 42\n"))
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+		assert.Equal(t, uint32(7), routes[0].Table)
+		assert.Equal(t, RouteScope(12), routes[0].Scope)
+		assert.Equal(t, RouteType(42), routes[0].Type)
+		assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), routes[0].Dst)
+	})
+
+	t.Run("Bad data", func(t *testing.T) {
+		_, err := ParseFibTrie(bytes.NewReader(fixtureFile(t, "randomData")))
+		assert.Error(t, err)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/netip"
 	"strings"
+	"time"
 )
 
 type NetRouteKind uint8
@@ -16,10 +17,101 @@ const (
 
 type NetRoute struct {
 	Kind        NetRouteKind
+	Type        RouteType
 	Destination string
 	Flags       string
 	Netif       string
 	Gateway     string
+
+	// Dst is the parsed destination prefix. It is only set by backends able
+	// to report prefix lengths.
+	Dst netip.Prefix
+	// Src is the source prefix of a source-specific route ("from" in
+	// `ip route`), as used by multihomed IPv6 networks. It is invalid for
+	// routes matching any source.
+	Src netip.Prefix
+	// Table is the routing table containing this route, or zero if unknown.
+	Table uint32
+	// Metric is the route's priority. Lower values are preferred.
+	Metric uint32
+	// TOS is the type of service matched by the route ("tos" in
+	// `ip route`). It is only reported by rtnetlink.
+	TOS uint8
+	// Protocol indicates the origin of the route.
+	Protocol RouteProtocol
+	// Scope indicates the distance to the destination.
+	Scope RouteScope
+	// PrefSrc is the preferred source address for traffic using this route
+	// ("src" in `ip route`), if any.
+	PrefSrc netip.Addr
+	// Metrics holds per-route metrics such as MTU and initial congestion
+	// window.
+	Metrics RouteMetrics
+	// NextHops lists every next hop of a multipath route. It is empty for
+	// routes with a single next hop, which is described by Gateway and
+	// Netif; for multipath routes, those fields mirror the first entry.
+	NextHops []NextHop
+	// HopFlags holds the flags of the next hop of routes having a single
+	// one. For multipath routes, flags are reported by each entry of
+	// NextHops.
+	HopFlags NextHopFlags
+	// NextHopID is the identifier of the nexthop object used by the route,
+	// if any. See ListNextHops.
+	NextHopID uint32
+	// Encap describes the lightweight tunnel encapsulation applied by the
+	// route, if any.
+	Encap *RouteEncap
+	// RefCnt and Use are the reference and lookup counters of the route, as
+	// shown by net-tools' route command. Other backends leave them zero.
+	RefCnt uint32
+	Use    uint32
+	// ExpiresAt is when the route expires, such as IPv6 defaults learned
+	// from router advertisements. It is zero for routes that don't expire,
+	// or whose backend doesn't report expiry.
+	ExpiresAt time.Time
+}
+
+// Lifetime returns the time left until the route expires, which is zero for
+// routes already expired but not yet removed. ok is false for routes that
+// don't expire.
+func (n NetRoute) Lifetime() (remaining time.Duration, ok bool) {
+	if n.ExpiresAt.IsZero() {
+		return 0, false
+	}
+	return max(time.Until(n.ExpiresAt), 0), true
+}
+
+// NextHopFlags represents the RTNH_F_* flags of a next hop.
+type NextHopFlags uint8
+
+const (
+	NextHopDead       NextHopFlags = 0x01
+	NextHopPervasive  NextHopFlags = 0x02
+	NextHopOnLink     NextHopFlags = 0x04
+	NextHopOffload    NextHopFlags = 0x08
+	NextHopLinkDown   NextHopFlags = 0x10
+	NextHopUnresolved NextHopFlags = 0x20
+)
+
+// NextHop represents a single next hop of a route.
+type NextHop struct {
+	Gateway string
+	Netif   string
+	// Weight is the relative weight of this next hop within a multipath
+	// route. Single next hops have a weight of 1.
+	Weight int
+	Flags  NextHopFlags
+	// Encap describes the encapsulation applied by this next hop, if any.
+	Encap *RouteEncap
+}
+
+// Hops returns the list of next hops of the route. For routes with a single
+// next hop, a list containing only its Gateway and Netif is returned.
+func (n NetRoute) Hops() []NextHop {
+	if len(n.NextHops) > 0 {
+		return n.NextHops
+	}
+	return []NextHop{{Gateway: n.Gateway, Netif: n.Netif, Weight: 1, Flags: n.HopFlags, Encap: n.Encap}}
 }
 
 func (n NetRoute) HasFlags(flags ...string) bool {
@@ -34,7 +126,17 @@ func (n NetRoute) HasFlags(flags ...string) bool {
 
 type NetRouteList []NetRoute
 
-func (n NetRouteList) FindDefaults(kind NetRouteKind) []NetRoute {
+// routeTableMain is the identifier of the main routing table, which is the
+// one considered by default when looking for default routes.
+const routeTableMain = 254
+
+// FindDefaults returns all default routes of the given kind in the main
+// routing table, or the one provided through InTable. Routes which don't
+// forward traffic, such as blackhole or unreachable defaults, are left out
+// unless IncludeNonForwarding is provided.
+func (n NetRouteList) FindDefaults(kind NetRouteKind, opts ...DefaultsOption) []NetRoute {
+	o := newDefaultsOptions(opts)
+
 	var filter func(r *NetRoute) bool
 	if kind == NetRouteKindV4 {
 		filter = func(r *NetRoute) bool {
@@ -57,138 +159,163 @@ func (n NetRouteList) FindDefaults(kind NetRouteKind) []NetRoute {
 	var result []NetRoute
 
 	for _, v := range n {
-		if v.Kind == kind && filter(&v) {
+		// Backends unable to report the table of routes only list the main
+		// one.
+		if v.Table != o.table && (v.Table != 0 || o.table != routeTableMain) {
+			continue
+		}
+		if v.Kind != kind || !o.acceptsProtocol(v.Protocol) {
+			continue
+		}
+		if !v.Type.Forwards() {
+			// Non-forwarding routes have no gateway to speak of.
+			if o.nonForwarding && v.isDefault() {
+				result = append(result, v)
+			}
+			continue
+		}
+		if filter(&v) {
 			result = append(result, v)
 		}
 	}
 
+	if o.source.IsValid() {
+		result = matchSource(result, kind, o.source)
+	}
 	return result
 }
 
-// FindDefaultGateways returns a list of addresses of all gateways used by
-// default routes, both IPv4 and IPv6.
-func FindDefaultGateways() ([]netip.Addr, error) {
-	routes, err := getRoutes()
-	if err != nil {
-		return nil, err
+// matchSource restricts routes to those the kernel would consider for traffic
+// from src: routes of other families are discarded, and among routes whose
+// source prefix contains src, only the most specific ones are kept. Routes
+// without a source prefix match any source.
+func matchSource(routes []NetRoute, kind NetRouteKind, src netip.Addr) []NetRoute {
+	src = src.WithZone("").Unmap()
+	if (kind == NetRouteKindV4) != src.Is4() {
+		return nil
 	}
-	var ips []netip.Addr
-	if rs := routes.FindDefaults(NetRouteKindV4); len(rs) > 0 {
-		for _, r := range rs {
-			v, err := netip.ParseAddr(r.Gateway)
-			if err != nil {
-				return nil, err
+	var result []NetRoute
+	best := -1
+	for _, r := range routes {
+		bits := 0
+		if r.Src.IsValid() {
+			if !r.Src.Contains(src) {
+				continue
 			}
-			ips = append(ips, v)
+			bits = r.Src.Bits()
 		}
-	}
-	if rs := routes.FindDefaults(NetRouteKindV6); len(rs) > 0 {
-		for _, r := range rs {
-			v, err := netip.ParseAddr(r.Gateway)
-			if err != nil {
-				return nil, err
-			}
-			ips = append(ips, v)
+		if bits > best {
+			result, best = result[:0], bits
+		}
+		if bits == best {
+			result = append(result, r)
 		}
 	}
-
-	return ips, nil
+	return result
 }
 
-// FindDefaultInterfaces returns a slice of strings containing the name of
-// interfaces using a default gateway.
-func FindDefaultInterfaces() ([]string, error) {
-	routes, err := getRoutes()
-	if err != nil {
-		return nil, err
+// isDefault returns whether the route's destination covers the whole address
+// space, regardless of flags and gateway.
+func (n NetRoute) isDefault() bool {
+	if n.Dst.IsValid() {
+		return n.Dst.Bits() == 0
 	}
-	var ifsMap []string
-	if rs := routes.FindDefaults(NetRouteKindV4); rs != nil {
-		for _, r := range rs {
-			_, err := netip.ParseAddr(r.Gateway)
-			if err != nil {
-				return nil, err
-			}
-			ifsMap = append(ifsMap, r.Netif)
-		}
+	switch n.Destination {
+	case "default", "0.0.0.0", "::", "::/0":
+		return true
 	}
-	if rs := routes.FindDefaults(NetRouteKindV6); rs != nil {
-		for _, r := range rs {
-			_, err := netip.ParseAddr(r.Gateway)
-			if err != nil {
-				return nil, err
+	return false
+}
+
+// defaultHops returns the next hops of all default routes, both IPv4 and
+// IPv6, ensuring their gateways are valid addresses. Hops without a gateway,
+// such as "nexthop dev wg0" in multipath routes, are skipped.
+func defaultHops(routes NetRouteList, opts []DefaultsOption) ([]NextHop, error) {
+	var hops []NextHop
+	for _, kind := range []NetRouteKind{NetRouteKindV4, NetRouteKindV6} {
+		for _, r := range routes.FindDefaults(kind, opts...) {
+			for _, h := range r.Hops() {
+				if h.Gateway == "" {
+					continue
+				}
+				if _, err := netip.ParseAddr(h.Gateway); err != nil {
+					return nil, err
+				}
+				hops = append(hops, h)
 			}
-			ifsMap = append(ifsMap, r.Netif)
 		}
 	}
-	return unique(ifsMap), nil
+	return hops, nil
 }
 
-// PickDefaultInterface picks the interface with most IPs based on the result of
-// FindDefaultInterfaces.
-func PickDefaultInterface() (string, error) {
-	ifaces, err := FindDefaultInterfaces()
-	if err != nil {
-		return "", err
-	}
+// FindDefaultRoutes returns all default routes, both IPv4 and IPv6. Unlike
+// FindDefaultGateways and FindDefaultInterfaces, the complete routes are
+// returned, allowing callers to identify defaults going through an
+// encapsulation (see NetRoute.IsEncapsulated), their origin, metrics, etc.
+func FindDefaultRoutes(opts ...DefaultsOption) (NetRouteList, error) {
+	return systemResolver().FindDefaultRoutes(opts...)
+}
 
-	ipCount := map[string]int{}
+// FindDefaultGateways returns a list of addresses of all gateways used by
+// default routes, both IPv4 and IPv6. Every next hop of multipath routes is
+// reported.
+func FindDefaultGateways(opts ...DefaultsOption) ([]netip.Addr, error) {
+	return systemResolver().FindDefaultGateways(opts...)
+}
 
-	for _, name := range ifaces {
-		iface, err := net.InterfaceByName(name)
-		if err != nil {
-			return "", err
-		}
-		addrs, err := iface.Addrs()
-		if err != nil {
-			return "", err
-		}
+// FindDefaultInterfaces returns a slice of strings containing the name of
+// interfaces using a default gateway.
+func FindDefaultInterfaces(opts ...DefaultsOption) ([]string, error) {
+	return systemResolver().FindDefaultInterfaces(opts...)
+}
 
-		ipCount[name] = len(addrs)
-	}
+// PickDefaultInterface picks the interface with most IPs based on the result of
+// FindDefaultInterfaces.
+func PickDefaultInterface(opts ...DefaultsOption) (string, error) {
+	return systemResolver().PickDefaultInterface(opts...)
+}
 
-	maxLen := 0
-	maxName := ""
-	for k, v := range ipCount {
-		if v > maxLen {
-			maxLen = v
-			maxName = k
-		}
-	}
+// FindDefaultIPs returns a list of IPs associated to all interfaces using a
+// default gateway. Tentative, deprecated or temporary IPv6 addresses can be
+// left out through ExcludeAddrFlags; see FindDefaultAddrs for their flags.
+func FindDefaultIPs(opts ...DefaultsOption) ([]netip.Addr, error) {
+	return systemResolver().FindDefaultIPs(opts...)
+}
 
-	return maxName, nil
+// FindDefaultSourceIPs returns the preferred source address of each default
+// route, both IPv4 and IPv6. For routes without a preferred source, the
+// address of the outgoing interface the kernel would most likely select is
+// returned instead.
+func FindDefaultSourceIPs(opts ...DefaultsOption) ([]netip.Addr, error) {
+	return systemResolver().FindDefaultSourceIPs(opts...)
 }
 
-// FindDefaultIPs returns a list of IPs associated to all interfaces using a
-// default gateway.
-func FindDefaultIPs() ([]netip.Addr, error) {
-	interfaces, err := FindDefaultInterfaces()
+var getRoutes func() (NetRouteList, Provenance, error) = nil
+
+// interfaceAddrs returns the addresses assigned to the named interface.
+var interfaceAddrs = func(name string) ([]InterfaceAddr, error) {
+	iface, err := net.InterfaceByName(name)
 	if err != nil {
 		return nil, err
 	}
-	var out []netip.Addr
-
-	for _, ifaceName := range interfaces {
-		iface, err := net.InterfaceByName(ifaceName)
-		if err != nil {
-			return nil, err
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var out []netip.Prefix
+	for _, v := range addrs {
+		ipNet, ok := v.(*net.IPNet)
+		if !ok {
+			continue
 		}
-		addrs, err := iface.Addrs()
-		if err != nil {
-			return nil, err
+		ip := ipNet.IP
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
 		}
-		for _, v := range addrs {
-			ip := v.(*net.IPNet).IP
-			if ip4 := ip.To4(); ip4 != nil {
-				ip = ip4
-			}
-			if add, ok := netip.AddrFromSlice(ip); ok {
-				out = append(out, add.WithZone(ifaceName))
-			}
+		if add, ok := netip.AddrFromSlice(ip); ok {
+			ones, _ := ipNet.Mask.Size()
+			out = append(out, netip.PrefixFrom(add, ones))
 		}
 	}
-
-	return out, nil
+	return withAddrFlags(name, out, addrFlags(iface.Index, name)), nil
 }
-
-var getRoutes func() (NetRouteList, error) = nil
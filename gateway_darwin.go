@@ -1,23 +1,29 @@
 package gateway
 
 import (
-	"os/exec"
+	"context"
 	"strings"
 )
 
 func init() {
-	getRoutes = func() (NetRouteList, error) {
-		cmd := exec.Command("netstat", "-rn")
-		output, err := cmd.CombinedOutput()
-		if err != nil {
+	routeSources[RouteSourceNetstat] = netstatRoutes
+	defaultRouteChain = RouteChain{RouteSourceNetstat}
+
+	getRoutes = func() (NetRouteList, Provenance, error) {
+		return defaultRouteChain.Routes(context.Background())
+	}
+}
+
+func netstatRoutes(ctx context.Context) (NetRouteList, error) {
+	output, err := runCommand(ctx, "netstat", "-rn")
+	if err != nil {
+		return nil, err
+	}
+	parser := newNetstatParser()
+	for _, line := range strings.Split(string(output), "\n") {
+		if err = parser.feed(line); err != nil {
 			return nil, err
 		}
-		parser := newNetstatParser()
-		for _, line := range strings.Split(string(output), "\n") {
-			if err = parser.feed(line); err != nil {
-				return nil, err
-			}
-		}
-		return parser.netData, nil
 	}
+	return parser.netData, nil
 }
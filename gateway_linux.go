@@ -1,17 +1,34 @@
 package gateway
 
+import (
+	"context"
+	"os"
+)
+
 func init() {
-	getRoutes = func() (NetRouteList, error) {
-		ip6List, err := getRoutesIPv6(routeV6)
-		if err != nil {
-			return nil, err
-		}
+	routeSources[RouteSourceNetlink] = func(context.Context) (NetRouteList, error) { return netlinkRoutes() }
+	routeSources[RouteSourceProc] = func(context.Context) (NetRouteList, error) { return procRoutes() }
+	routeSources[RouteSourceIPJSON] = ipRouteJSONRoutes
+	routeSources[RouteSourceIPText] = ipRouteTextRoutes
+	routeSources[RouteSourceNetstat] = netToolsRoutes
 
-		ip4List, err := getRoutesIPv4(routeV4)
-		if err != nil {
-			return nil, err
-		}
+	// rtnetlink provides all tables and multipath routes, while procfs only
+	// lists the main table, and at most one next hop per route. Some
+	// systems, such as Android, deny access to procfs while still allowing
+	// ip to run. BusyBox's ip has no JSON output.
+	defaultRouteChain = RouteChain{
+		RouteSourceNetlink,
+		RouteSourceProc,
+		RouteSourceIPJSON,
+		RouteSourceIPText,
+		RouteSourceNetstat,
+	}
 
-		return append(ip4List, ip6List...), nil
+	getRoutes = func() (NetRouteList, Provenance, error) {
+		return defaultRouteChain.Routes(context.Background())
 	}
 }
+
+func procRoutes() (NetRouteList, error) {
+	return readProcRoutes(os.ReadFile, procNetDir)
+}
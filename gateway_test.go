@@ -3,10 +3,12 @@ package gateway
 import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/netip"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 )
 
 func fixtureFile(t *testing.T, name string) []byte {
@@ -23,14 +25,14 @@ func fixtureFilePath(name string) string {
 func setNetstatSource(t *testing.T, source string) {
 	t.Helper()
 	prevRoutes := getRoutes
-	getRoutes = func() (NetRouteList, error) {
+	getRoutes = func() (NetRouteList, Provenance, error) {
 		parser := newNetstatParser()
 		for _, line := range strings.Split(string(fixtureFile(t, source)), "\n") {
 			if err := parser.feed(line); err != nil {
-				return nil, err
+				return nil, Provenance{}, err
 			}
 		}
-		return parser.netData, nil
+		return parser.netData, Provenance{Source: RouteSourceNetstat}, nil
 	}
 	t.Cleanup(func() {
 		getRoutes = prevRoutes
@@ -40,30 +42,52 @@ func setNetstatSource(t *testing.T, source string) {
 func setProcSource(t *testing.T, ipv4, ipv6 string) {
 	t.Helper()
 	prevRoutes := getRoutes
-	getRoutes = func() (NetRouteList, error) {
+	getRoutes = func() (NetRouteList, Provenance, error) {
 		var ip6List, ip4List NetRouteList
 		var err error
 		if ipv6 != "" {
-			ip6List, err = getRoutesIPv6(fixtureFilePath(ipv6))
+			ip6List, err = getRoutesIPv6(os.ReadFile, fixtureFilePath(ipv6))
 		}
 		if err != nil {
-			return nil, err
+			return nil, Provenance{}, err
 		}
 
 		if ipv4 != "" {
-			ip4List, err = getRoutesIPv4(fixtureFilePath(ipv4))
+			ip4List, err = getRoutesIPv4(os.ReadFile, fixtureFilePath(ipv4))
 		}
 		if err != nil {
-			return nil, err
+			return nil, Provenance{}, err
 		}
 
-		return append(ip4List, ip6List...), nil
+		return append(ip4List, ip6List...), Provenance{Source: RouteSourceProc}, nil
 	}
 	t.Cleanup(func() {
 		getRoutes = prevRoutes
 	})
 }
 
+func setRoutes(t *testing.T, routes NetRouteList) {
+	t.Helper()
+	prevRoutes := getRoutes
+	getRoutes = func() (NetRouteList, Provenance, error) {
+		return routes, Provenance{}, nil
+	}
+	t.Cleanup(func() {
+		getRoutes = prevRoutes
+	})
+}
+
+func setInterfaceAddrs(t *testing.T, addrs map[string][]netip.Prefix) {
+	t.Helper()
+	prevAddrs := interfaceAddrs
+	interfaceAddrs = func(name string) ([]InterfaceAddr, error) {
+		return withAddrFlags(name, addrs[name], nil), nil
+	}
+	t.Cleanup(func() {
+		interfaceAddrs = prevAddrs
+	})
+}
+
 func TestDarwin(t *testing.T) {
 	t.Run("Sane", func(t *testing.T) {
 		setNetstatSource(t, "darwin")
@@ -100,8 +124,21 @@ func TestLinux(t *testing.T) {
 		setProcSource(t, "linuxipv4", "linuxipv6")
 		ifaces, err := FindDefaultInterfaces()
 		require.NoError(t, err)
-		assert.Len(t, ifaces, 1)
-		assert.Equal(t, "wlp4s0", ifaces[0])
+		// The IPv6 default of ens34 is only recognized since destination
+		// prefixes of ipv6_route are parsed.
+		assert.ElementsMatch(t, []string{"wlp4s0", "ens34"}, ifaces)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		setProcSource(t, "linuxipv4", "linuxipv6")
+		routes, err := systemResolver().Routes()
+		require.NoError(t, err)
+		v4 := routes.FindDefaults(NetRouteKindV4)
+		require.Len(t, v4, 1)
+		assert.Equal(t, uint32(600), v4[0].Metric)
+		v6 := routes.FindDefaults(NetRouteKindV6)
+		require.Len(t, v6, 1)
+		assert.Equal(t, uint32(100), v6[0].Metric)
 	})
 
 	t.Run("No Route", func(t *testing.T) {
@@ -125,3 +162,171 @@ func TestLinux(t *testing.T) {
 		assert.Len(t, ifaces, 0)
 	})
 }
+
+func TestMultipath(t *testing.T) {
+	setRoutes(t, NetRouteList{
+		{
+			Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Table: 254,
+			Gateway: "10.0.0.1", Netif: "eth0",
+			NextHops: []NextHop{
+				{Gateway: "10.0.0.1", Netif: "eth0", Weight: 2},
+				{Gateway: "10.1.0.1", Netif: "eth1", Weight: 1},
+				{Netif: "wg0", Weight: 1},
+			},
+		},
+		{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Table: 100, Gateway: "10.2.0.1", Netif: "eth2"},
+	})
+
+	gateways, err := FindDefaultGateways()
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.1.0.1")}, gateways)
+
+	ifaces, err := FindDefaultInterfaces()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"eth0", "eth1"}, ifaces)
+}
+
+func TestRouteTypes(t *testing.T) {
+	t.Run("Darwin", func(t *testing.T) {
+		setNetstatSource(t, "darwinBlackhole")
+		ifaces, err := FindDefaultInterfaces()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"en0"}, ifaces)
+
+		routes, err := systemResolver().Routes()
+		require.NoError(t, err)
+		v4 := routes.FindDefaults(NetRouteKindV4, IncludeNonForwarding())
+		require.Len(t, v4, 2)
+		assert.Equal(t, RouteTypeBlackhole, v4[0].Type)
+		assert.Equal(t, RouteTypeUnicast, v4[1].Type)
+
+		v6 := routes.FindDefaults(NetRouteKindV6, IncludeNonForwarding())
+		require.Len(t, v6, 1)
+		assert.Equal(t, RouteTypeUnreachable, v6[0].Type)
+		assert.Empty(t, routes.FindDefaults(NetRouteKindV6))
+	})
+
+	t.Run("Linux", func(t *testing.T) {
+		setProcSource(t, "linuxUnreachable", "")
+		routes, err := systemResolver().Routes()
+		require.NoError(t, err)
+		require.Len(t, routes, 3)
+		assert.Equal(t, RouteTypeUnreachable, routes[0].Type)
+		// Blackhole and throw routes can't be told apart.
+		assert.Equal(t, RouteTypeUnspec, routes[1].Type)
+		assert.Equal(t, RouteTypeUnicast, routes[2].Type)
+
+		assert.Empty(t, routes.FindDefaults(NetRouteKindV4))
+		assert.Len(t, routes.FindDefaults(NetRouteKindV4, IncludeNonForwarding()), 1)
+	})
+}
+
+func TestFindDefaultSourceIPs(t *testing.T) {
+	setRoutes(t, NetRouteList{
+		{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0", PrefSrc: netip.MustParseAddr("10.0.0.3")},
+		{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.1.0.1", Netif: "eth1", Metric: 100},
+		{Kind: NetRouteKindV6, Destination: "default", Flags: "UG", Gateway: "fe80::1", Netif: "eth1"},
+		{Kind: NetRouteKindV4, Destination: "default", Type: RouteTypeBlackhole, Metric: 200},
+	})
+	setInterfaceAddrs(t, map[string][]netip.Prefix{
+		"eth0": {netip.MustParsePrefix("10.0.0.2/24"), netip.MustParsePrefix("10.0.0.3/24")},
+		"eth1": {
+			netip.MustParsePrefix("192.168.0.2/24"),
+			netip.MustParsePrefix("10.1.0.2/24"),
+			netip.MustParsePrefix("fe80::2/64"),
+			netip.MustParsePrefix("2001:db8::2/64"),
+		},
+	})
+
+	expected := []netip.Addr{
+		netip.MustParseAddr("10.0.0.3"),
+		netip.MustParseAddr("10.1.0.2"),
+		netip.MustParseAddr("2001:db8::2"),
+	}
+	ips, err := FindDefaultSourceIPs()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, ips)
+
+	ips, err = FindDefaultSourceIPs(IncludeNonForwarding())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, ips)
+}
+
+func TestProcMetrics(t *testing.T) {
+	setProcSource(t, "linuxMetrics", "")
+	routes, err := systemResolver().Routes()
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, RouteMetrics{AdvMSS: 1400, Window: 65535, RTT: 300 * time.Millisecond}, routes[0].Metrics)
+	assert.Equal(t, RouteMetrics{}, routes[1].Metrics)
+}
+
+func TestFindDefaultRoutes(t *testing.T) {
+	setRoutes(t, NetRouteList{
+		{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0"},
+		{Kind: NetRouteKindV4, Destination: "10.0.0.0/8", Flags: "U", Netif: "eth0"},
+		{
+			Kind: NetRouteKindV6, Destination: "default", Flags: "UG", Gateway: "2001:db8::1", Netif: "eth0",
+			Encap: &RouteEncap{Type: EncapTypeSeg6, Segments: []netip.Addr{netip.MustParseAddr("2001:db8::10")}},
+		},
+	})
+
+	routes, err := FindDefaultRoutes()
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.False(t, routes[0].IsEncapsulated())
+	assert.True(t, routes[1].IsEncapsulated())
+}
+
+func TestSourceSpecificDefaults(t *testing.T) {
+	setProcSource(t, "", "linuxSourceSpecific")
+
+	routes, err := FindDefaultRoutes()
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "::/0", routes[0].Destination)
+	assert.Equal(t, netip.MustParsePrefix("::/0"), routes[0].Dst)
+	assert.Equal(t, netip.MustParsePrefix("2001:db8:1::/48"), routes[0].Src)
+	assert.Equal(t, netip.MustParsePrefix("2001:db8:2::/48"), routes[1].Src)
+
+	for src, gateway := range map[string]string{
+		"2001:db8:1:1::10": "fe80::1",
+		"2001:db8:2:1::10": "fe80::2",
+	} {
+		gateways, err := FindDefaultGateways(FromSource(netip.MustParseAddr(src)))
+		require.NoError(t, err)
+		assert.Equal(t, []netip.Addr{netip.MustParseAddr(gateway)}, gateways, src)
+	}
+
+	gateways, err := FindDefaultGateways(FromSource(netip.MustParseAddr("2001:db8:3::10")))
+	require.NoError(t, err)
+	assert.Empty(t, gateways)
+
+	gateways, err = FindDefaultGateways(FromSource(netip.MustParseAddr("192.0.2.1")))
+	require.NoError(t, err)
+	assert.Empty(t, gateways)
+}
+
+func TestDarwinExpire(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	parser := newNetstatParser()
+	parser.now = func() time.Time { return now }
+	for _, line := range strings.Split(string(fixtureFile(t, "darwinExpire")), "\n") {
+		require.NoError(t, parser.feed(line))
+	}
+	routes := parser.result()
+
+	v4 := routes.FindDefaults(NetRouteKindV4)
+	require.Len(t, v4, 1)
+	assert.True(t, v4[0].ExpiresAt.IsZero())
+	_, expires := v4[0].Lifetime()
+	assert.False(t, expires)
+
+	v6 := routes.FindDefaults(NetRouteKindV6)
+	require.Len(t, v6, 2)
+	assert.Equal(t, now.Add(1674*time.Second), v6[0].ExpiresAt)
+	assert.Equal(t, now, v6[1].ExpiresAt)
+	remaining, expires := v6[1].Lifetime()
+	assert.True(t, expires)
+	assert.Zero(t, remaining)
+}
@@ -3,7 +3,7 @@
 package gateway
 
 func init() {
-	getRoutes = func() (NetRouteList, error) {
-		return nil, ErrNotImplemented{}
+	getRoutes = func() (NetRouteList, Provenance, error) {
+		return nil, Provenance{}, &ErrNotImplemented{}
 	}
 }
@@ -0,0 +1,361 @@
+package gateway
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// GatewayEventType indicates how the primary default of a family changed.
+type GatewayEventType uint8
+
+const (
+	// GatewayGained indicates a default appeared for a family which had
+	// none.
+	GatewayGained GatewayEventType = iota + 1
+	// GatewayLost indicates the last default of a family went away.
+	GatewayLost
+	// GatewayChanged indicates the primary default now goes through another
+	// gateway of the same interface.
+	GatewayChanged
+	// InterfaceChanged indicates the primary default now goes through
+	// another interface, such as when a VPN connects. The gateway usually
+	// changes as well.
+	InterfaceChanged
+)
+
+var gatewayEventTypeNames = [...]string{
+	GatewayGained:    "gained",
+	GatewayLost:      "lost",
+	GatewayChanged:   "gateway-changed",
+	InterfaceChanged: "interface-changed",
+}
+
+func (t GatewayEventType) String() string {
+	if t > 0 && int(t) < len(gatewayEventTypeNames) {
+		return gatewayEventTypeNames[t]
+	}
+	return "unknown"
+}
+
+// DefaultGateway describes the primary default route of a family, which is
+// the one with the lowest metric.
+type DefaultGateway struct {
+	// Gateway is the address of the first next hop of the route. It is
+	// invalid for defaults without a gateway, such as blackhole defaults
+	// reported through IncludeNonForwarding.
+	Gateway netip.Addr
+	Netif   string
+	Route   NetRoute
+}
+
+// IsValid reports whether d describes a route, as opposed to the absence of
+// a default.
+func (d DefaultGateway) IsValid() bool {
+	return d.Route.Kind != 0
+}
+
+func (d DefaultGateway) same(o DefaultGateway) bool {
+	return d.IsValid() == o.IsValid() && d.Gateway == o.Gateway && d.Netif == o.Netif
+}
+
+// GatewayEvent describes a change of the primary default of a family.
+type GatewayEvent struct {
+	Type GatewayEventType
+	Kind NetRouteKind
+	// Current is the new primary default. It is the zero value for
+	// GatewayLost.
+	Current DefaultGateway
+	// Previous is the former primary default. It is the zero value for
+	// GatewayGained.
+	Previous DefaultGateway
+}
+
+const defaultGatewayDebounce = 250 * time.Millisecond
+
+// GatewayWatchOption customizes how WatchDefaultGateways derives events.
+type GatewayWatchOption func(*gatewayWatchOptions)
+
+type gatewayWatchOptions struct {
+	debounce   time.Duration
+	hysteresis time.Duration
+	defaults   []DefaultsOption
+	poll       []PollOption
+	polling    bool
+	clock      Clock
+}
+
+func newGatewayWatchOptions(opts []GatewayWatchOption) *gatewayWatchOptions {
+	o := &gatewayWatchOptions{
+		debounce: defaultGatewayDebounce,
+		clock:    systemClock{},
+	}
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
+// WithGatewayDebounce sets how long route events must stop arriving before
+// defaults are evaluated again, so that a burst of changes, such as a
+// default being replaced by removing it and adding another one, is seen as
+// a whole. It defaults to 250ms; zero evaluates defaults on every event.
+func WithGatewayDebounce(d time.Duration) GatewayWatchOption {
+	return func(o *gatewayWatchOptions) {
+		o.debounce = d
+	}
+}
+
+// WithHysteresis sets how long a change of the primary default must last
+// before being reported. Changes reverted within d, such as a default
+// briefly removed while a DHCP lease is renewed, aren't reported at all. It
+// is disabled by default.
+func WithHysteresis(d time.Duration) GatewayWatchOption {
+	return func(o *gatewayWatchOptions) {
+		o.hysteresis = d
+	}
+}
+
+// WithDefaultsOptions sets options used to find default routes, such as
+// InTable or ExcludeProtocols.
+func WithDefaultsOptions(opts ...DefaultsOption) GatewayWatchOption {
+	return func(o *gatewayWatchOptions) {
+		o.defaults = append(o.defaults, opts...)
+	}
+}
+
+// WithPolling makes the package-level WatchDefaultGateways poll routes using
+// the provided options, instead of relying on WatchRoutes. Watchers created
+// through a Resolver always poll.
+func WithPolling(opts ...PollOption) GatewayWatchOption {
+	return func(o *gatewayWatchOptions) {
+		o.polling = true
+		o.poll = append(o.poll, opts...)
+	}
+}
+
+// WithGatewayClock makes the watcher use the provided clock instead of the
+// system's, including for polling.
+func WithGatewayClock(c Clock) GatewayWatchOption {
+	return func(o *gatewayWatchOptions) {
+		o.clock = c
+	}
+}
+
+// GatewayWatcher delivers gateway events until its context is done, or it
+// fails.
+type GatewayWatcher struct {
+	events chan GatewayEvent
+	err    error
+}
+
+// Events returns the channel events are delivered on. It is closed once the
+// watcher stops.
+func (w *GatewayWatcher) Events() <-chan GatewayEvent {
+	return w.events
+}
+
+// Err returns the error which stopped the watcher, once Events is closed. It
+// is nil for watchers stopped by their context.
+func (w *GatewayWatcher) Err() error {
+	return w.err
+}
+
+// WatchDefaultGateways returns a GatewayWatcher reporting changes of the
+// primary default of each family, the one with the lowest metric, ignoring
+// other route changes. Routes are followed through WatchRoutes, unless
+// WithPolling is provided. Defaults present when the watcher starts are not
+// reported.
+func WatchDefaultGateways(ctx context.Context, opts ...GatewayWatchOption) (*GatewayWatcher, error) {
+	o := newGatewayWatchOptions(opts)
+	if o.polling {
+		return systemResolver().watchDefaultGateways(ctx, o)
+	}
+	rw, err := WatchRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return watchGateways(ctx, rw, o), nil
+}
+
+// WatchDefaultGateways is like the package-level WatchDefaultGateways,
+// polling routes provided by the resolver.
+func (res *Resolver) WatchDefaultGateways(ctx context.Context, opts ...GatewayWatchOption) (*GatewayWatcher, error) {
+	return res.watchDefaultGateways(ctx, newGatewayWatchOptions(opts))
+}
+
+func (res *Resolver) watchDefaultGateways(ctx context.Context, o *gatewayWatchOptions) (*GatewayWatcher, error) {
+	poll := append([]PollOption{WithClock(o.clock)}, o.poll...)
+	rw, err := res.PollRoutes(ctx, poll...)
+	if err != nil {
+		return nil, err
+	}
+	return watchGateways(ctx, rw, o), nil
+}
+
+// gatewayKinds lists families in the order their events are delivered.
+var gatewayKinds = [...]NetRouteKind{NetRouteKindV4, NetRouteKindV6}
+
+// gatewayState tracks routes known to a gateway watcher, remembering the
+// order they were seen in, which breaks ties between defaults of the same
+// metric.
+type gatewayState struct {
+	routes routeState
+	order  map[routeKey]uint64
+	seq    uint64
+}
+
+func newGatewayState(routes NetRouteList) *gatewayState {
+	s := &gatewayState{routes: routeState{}, order: map[routeKey]uint64{}}
+	for _, r := range routes {
+		s.apply(RouteEvent{Type: RouteAdded, Route: r})
+	}
+	return s
+}
+
+func (s *gatewayState) apply(e RouteEvent) {
+	k := keyOf(&e.Route)
+	if e.Type == RouteRemoved {
+		delete(s.routes, k)
+		delete(s.order, k)
+		return
+	}
+	if _, ok := s.order[k]; !ok {
+		s.order[k] = s.seq
+		s.seq++
+	}
+	s.routes[k] = e.Route
+}
+
+// primaries returns the primary default of each family. Among defaults of
+// the same metric, the one matching current is kept, so that the primary
+// doesn't move around; otherwise, the first one seen is picked.
+func (s *gatewayState) primaries(current [len(gatewayKinds)]DefaultGateway, opts []DefaultsOption) [len(gatewayKinds)]DefaultGateway {
+	routes := make(NetRouteList, 0, len(s.routes))
+	for _, r := range s.routes {
+		routes = append(routes, r)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return s.order[keyOf(&routes[i])] < s.order[keyOf(&routes[j])]
+	})
+
+	var result [len(gatewayKinds)]DefaultGateway
+	for i, kind := range gatewayKinds {
+		var best DefaultGateway
+		for _, r := range routes.FindDefaults(kind, opts...) {
+			d := DefaultGateway{Netif: r.Netif, Route: r}
+			d.Gateway, _ = netip.ParseAddr(r.Gateway)
+			switch {
+			case !best.IsValid(), r.Metric < best.Route.Metric:
+				best = d
+			case r.Metric == best.Route.Metric && d.same(current[i]) && !best.same(current[i]):
+				best = d
+			}
+		}
+		result[i] = best
+	}
+	return result
+}
+
+// watchGateways derives gateway events from the route events of rw.
+func watchGateways(ctx context.Context, rw *RouteWatcher, o *gatewayWatchOptions) *GatewayWatcher {
+	w := &GatewayWatcher{events: make(chan GatewayEvent)}
+	go w.watch(ctx, rw, o)
+	return w
+}
+
+func (w *GatewayWatcher) watch(ctx context.Context, rw *RouteWatcher, o *gatewayWatchOptions) {
+	defer close(w.events)
+	state := newGatewayState(rw.initial)
+	var empty [len(gatewayKinds)]DefaultGateway
+	reported := state.primaries(empty, o.defaults)
+	candidate := reported
+
+	// settle fires once route events stopped arriving for the debounce
+	// period, and hold once a candidate lasted for the hysteresis period.
+	var settle, hold <-chan time.Time
+
+	evaluate := func() bool {
+		next := state.primaries(reported, o.defaults)
+		if sameDefaults(next, reported) {
+			// Keep attributes such as metrics current, without reporting.
+			reported, candidate, hold = next, next, nil
+			return true
+		}
+		if o.hysteresis <= 0 {
+			candidate = next
+			return w.report(ctx, &reported, candidate)
+		}
+		if hold == nil || !sameDefaults(next, candidate) {
+			hold = o.clock.After(o.hysteresis)
+		}
+		candidate = next
+		return true
+	}
+
+	for {
+		select {
+		case e, ok := <-rw.Events():
+			if !ok {
+				w.err = rw.Err()
+				return
+			}
+			state.apply(e)
+			if o.debounce > 0 {
+				settle = o.clock.After(o.debounce)
+			} else if !evaluate() {
+				return
+			}
+		case <-settle:
+			settle = nil
+			if !evaluate() {
+				return
+			}
+		case <-hold:
+			hold = nil
+			if !w.report(ctx, &reported, candidate) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func sameDefaults(a, b [len(gatewayKinds)]DefaultGateway) bool {
+	for i := range a {
+		if !a[i].same(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// report delivers events describing the differences between reported and
+// next, and records next as reported. It returns false if ctx is done first.
+func (w *GatewayWatcher) report(ctx context.Context, reported *[len(gatewayKinds)]DefaultGateway, next [len(gatewayKinds)]DefaultGateway) bool {
+	for i, kind := range gatewayKinds {
+		prev, cur := reported[i], next[i]
+		var t GatewayEventType
+		switch {
+		case prev.same(cur):
+			continue
+		case !prev.IsValid():
+			t = GatewayGained
+		case !cur.IsValid():
+			t = GatewayLost
+		case prev.Netif != cur.Netif:
+			t = InterfaceChanged
+		default:
+			t = GatewayChanged
+		}
+		select {
+		case w.events <- GatewayEvent{Type: t, Kind: kind, Current: cur, Previous: prev}:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	*reported = next
+	return true
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"net/netip"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchDefaultGateways(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"link", "add", "d0", "type", "veth", "peer", "name", "d1"},
+		{"link", "set", "d0", "addrgenmode", "none"},
+		{"link", "set", "d1", "addrgenmode", "none"},
+		{"link", "set", "d1", "up"},
+		{"link", "set", "d0", "up"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	var w *GatewayWatcher
+	require.NoError(t, ns.Do(func() (err error) {
+		w, err = WatchDefaultGateways(ctx, WithGatewayDebounce(0))
+		return err
+	}))
+
+	// The default keeps its key, being replaced in place.
+	require.NoError(t, ns.Do(func() error {
+		return exec.Command("ip", "route", "replace", "default", "via", "10.9.0.7", "dev", "d0", "onlink").Run()
+	}))
+	select {
+	case e, ok := <-w.Events():
+		require.True(t, ok, "watcher stopped: %v", w.Err())
+		assert.Equal(t, InterfaceChanged, e.Type)
+		assert.Equal(t, NetRouteKindV4, e.Kind)
+		assert.Equal(t, netip.MustParseAddr("10.9.0.7"), e.Current.Gateway)
+		assert.Equal(t, "d0", e.Current.Netif)
+		assert.Equal(t, netip.MustParseAddr("10.9.0.1"), e.Previous.Gateway)
+		assert.Equal(t, "lo", e.Previous.Netif)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+	}
+
+	cancel()
+	for range w.Events() {
+	}
+	assert.NoError(t, w.Err())
+}
@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchGateways(t *testing.T) {
+	eth := NetRoute{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0", Metric: 100}
+	eth2 := eth
+	eth2.Gateway = "10.0.0.2"
+	vpn := NetRoute{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.8.0.1", Netif: "tun0", Metric: 50}
+	lan := NetRoute{Kind: NetRouteKindV4, Destination: "10.0.0.0", Flags: "U", Netif: "eth0", Metric: 100}
+	ra := NetRoute{Kind: NetRouteKindV6, Destination: "::/0", Flags: "UG", Gateway: "2001:db8::1", Netif: "eth0", Metric: 1024}
+
+	primary := func(r NetRoute) DefaultGateway {
+		return DefaultGateway{Gateway: netip.MustParseAddr(r.Gateway), Netif: r.Netif, Route: r}
+	}
+	start := func(t *testing.T, opts ...GatewayWatchOption) (*RouteWatcher, *GatewayWatcher, *fakeClock) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		clock := newFakeClock()
+		rw := newRouteWatcher(NetRouteList{eth, lan})
+		opts = append([]GatewayWatchOption{WithGatewayClock(clock)}, opts...)
+		return rw, watchGateways(ctx, rw, newGatewayWatchOptions(opts)), clock
+	}
+
+	t.Run("Events", func(t *testing.T) {
+		rw, w, _ := start(t, WithGatewayDebounce(0))
+
+		rw.events <- RouteEvent{Type: RouteChanged, Route: eth2, Previous: eth}
+		assert.Equal(t, GatewayEvent{Type: GatewayChanged, Kind: NetRouteKindV4, Current: primary(eth2), Previous: primary(eth)}, <-w.Events())
+
+		rw.events <- RouteEvent{Type: RouteAdded, Route: vpn}
+		assert.Equal(t, GatewayEvent{Type: InterfaceChanged, Kind: NetRouteKindV4, Current: primary(vpn), Previous: primary(eth2)}, <-w.Events())
+
+		// Neither other routes nor backup defaults matter.
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: lan}
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: eth2}
+
+		rw.events <- RouteEvent{Type: RouteAdded, Route: ra}
+		assert.Equal(t, GatewayEvent{Type: GatewayGained, Kind: NetRouteKindV6, Current: primary(ra)}, <-w.Events())
+
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: vpn}
+		assert.Equal(t, GatewayEvent{Type: GatewayLost, Kind: NetRouteKindV4, Previous: primary(vpn)}, <-w.Events())
+
+		rw.err = errors.New("failed")
+		close(rw.events)
+		_, ok := <-w.Events()
+		assert.False(t, ok)
+		assert.Equal(t, rw.err, w.Err())
+	})
+
+	t.Run("Ties", func(t *testing.T) {
+		rw, w, _ := start(t, WithGatewayDebounce(0))
+
+		// Defaults of the same metric don't take over the primary.
+		wifi := NetRoute{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "192.168.1.1", Netif: "wlan0", Metric: 100}
+		rw.events <- RouteEvent{Type: RouteAdded, Route: wifi}
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: eth}
+		assert.Equal(t, GatewayEvent{Type: InterfaceChanged, Kind: NetRouteKindV4, Current: primary(wifi), Previous: primary(eth)}, <-w.Events())
+		rw.events <- RouteEvent{Type: RouteAdded, Route: eth}
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: wifi}
+		assert.Equal(t, GatewayEvent{Type: InterfaceChanged, Kind: NetRouteKindV4, Current: primary(eth), Previous: primary(wifi)}, <-w.Events())
+	})
+
+	t.Run("Debounce", func(t *testing.T) {
+		rw, w, clock := start(t, WithGatewayDebounce(time.Second))
+
+		// Replacing the default is not reported as losing it.
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: eth}
+		rw.events <- RouteEvent{Type: RouteAdded, Route: eth2}
+		<-clock.requests
+		assert.Equal(t, time.Second, clock.elapse(t))
+		assert.Equal(t, GatewayEvent{Type: GatewayChanged, Kind: NetRouteKindV4, Current: primary(eth2), Previous: primary(eth)}, <-w.Events())
+	})
+
+	t.Run("Hysteresis", func(t *testing.T) {
+		rw, w, clock := start(t, WithGatewayDebounce(0), WithHysteresis(5*time.Second))
+
+		// A default going away briefly is not reported.
+		rw.events <- RouteEvent{Type: RouteRemoved, Route: eth}
+		<-clock.requests
+		rw.events <- RouteEvent{Type: RouteAdded, Route: eth}
+
+		rw.events <- RouteEvent{Type: RouteAdded, Route: vpn}
+		assert.Equal(t, 5*time.Second, clock.elapse(t))
+		assert.Equal(t, GatewayEvent{Type: InterfaceChanged, Kind: NetRouteKindV4, Current: primary(vpn), Previous: primary(eth)}, <-w.Events())
+	})
+}
+
+func TestWatchGatewaysProcMetrics(t *testing.T) {
+	root := procRoot(t, "1", map[string]string{"route": "linuxTwoDefaults", "dev": "procNetDev"})
+	res, err := NewResolver(WithRoot(root))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock()
+	w, err := res.WatchDefaultGateways(ctx,
+		WithGatewayClock(clock),
+		WithGatewayDebounce(0),
+		WithPolling(WithPollInterval(time.Minute), WithDebounce(0)))
+	require.NoError(t, err)
+
+	// The default of eth1 is the primary one despite being listed second,
+	// having the lowest metric, so that losing it is reported.
+	routes := strings.SplitAfter(string(fixtureFile(t, "linuxTwoDefaults")), "\n")
+	routes = append(routes[:3], routes[4:]...)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "proc", "1", "net", "route"), []byte(strings.Join(routes, "")), 0o644))
+	clock.elapse(t)
+
+	select {
+	case e := <-w.Events():
+		assert.Equal(t, InterfaceChanged, e.Type)
+		assert.Equal(t, "eth0", e.Current.Netif)
+		assert.Equal(t, uint32(600), e.Current.Route.Metric)
+		assert.Equal(t, "eth1", e.Previous.Netif)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+	}
+}
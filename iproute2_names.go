@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// iproute2ConfigDirs lists directories holding iproute2 name databases such
+// as rt_protos and rt_tables, in increasing order of precedence. Recent
+// iproute2 releases ship defaults under /usr/share, overridable in /etc.
+var iproute2ConfigDirs = []string{"/usr/share/iproute2", "/etc/iproute2"}
+
+// loadIPRoute2Names reads the given iproute2 database (e.g. "rt_protos") and
+// its ".d" directory from all iproute2ConfigDirs, returning a map of numeric
+// identifiers to names. Missing or unreadable files are ignored.
+func loadIPRoute2Names(db string) map[uint32]string {
+	names := map[uint32]string{}
+	for _, dir := range iproute2ConfigDirs {
+		readIPRoute2NamesFile(filepath.Join(dir, db), names)
+		confs, _ := filepath.Glob(filepath.Join(dir, db+".d", "*.conf"))
+		for _, v := range confs {
+			readIPRoute2NamesFile(v, names)
+		}
+	}
+	return names
+}
+
+func readIPRoute2NamesFile(path string, into map[uint32]string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	parseIPRoute2Names(f, into)
+}
+
+// parseIPRoute2Names parses lines in the "<id> <name>" format used by
+// iproute2 databases. Identifiers may be decimal or hexadecimal, and
+// everything after a '#' is a comment.
+func parseIPRoute2Names(r io.Reader, into map[uint32]string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, '#'); idx != -1 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[0], 0, 32)
+		if err != nil {
+			continue
+		}
+		into[uint32(id)] = fields[1]
+	}
+}
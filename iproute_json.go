@@ -0,0 +1,345 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonFields holds every value of each key of a JSON object. iproute2 emits
+// duplicate keys in some cases, such as "dst" for both a route and its
+// encapsulation, which encoding/json would silently merge.
+type jsonFields map[string][]json.RawMessage
+
+func (f *jsonFields) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return &ErrCantParse{}
+	}
+	*f = jsonFields{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return &ErrCantParse{}
+		}
+		var v json.RawMessage
+		if err = dec.Decode(&v); err != nil {
+			return err
+		}
+		(*f)[key] = append((*f)[key], v)
+	}
+	return nil
+}
+
+// decode unmarshals the idx-th value of key into v, returning whether it
+// was present and valid.
+func (f jsonFields) decode(key string, idx int, v any) bool {
+	values := f[key]
+	if idx < 0 || idx >= len(values) {
+		return false
+	}
+	return json.Unmarshal(values[idx], v) == nil
+}
+
+func (f jsonFields) string(key string) string {
+	var s string
+	f.decode(key, 0, &s)
+	return s
+}
+
+func (f jsonFields) uint32(key string) (uint32, bool) {
+	var v uint32
+	ok := f.decode(key, 0, &v)
+	return v, ok
+}
+
+func (f jsonFields) addr(key string) netip.Addr {
+	a, _ := netip.ParseAddr(f.string(key))
+	return a
+}
+
+// gateway returns the next hop address from either "gateway", or "via" for
+// next hops from a different family.
+func (f jsonFields) gateway() string {
+	if gw := f.string("gateway"); gw != "" {
+		return gw
+	}
+	var via struct {
+		Host string `json:"host"`
+	}
+	f.decode("via", 0, &via)
+	return via.Host
+}
+
+// ipRouteHopFlags maps the names ip prints next hop flags under.
+var ipRouteHopFlags = map[string]NextHopFlags{
+	"dead":       NextHopDead,
+	"pervasive":  NextHopPervasive,
+	"onlink":     NextHopOnLink,
+	"offload":    NextHopOffload,
+	"linkdown":   NextHopLinkDown,
+	"unresolved": NextHopUnresolved,
+}
+
+func (f jsonFields) hopFlags() NextHopFlags {
+	var names []string
+	f.decode("flags", 0, &names)
+	var flags NextHopFlags
+	for _, v := range names {
+		flags |= ipRouteHopFlags[v]
+	}
+	return flags
+}
+
+// ParseIPRouteJSON parses the output of `ip -j -d route show`, for routes of
+// the given family. Routing table and protocol names are resolved through
+// the iproute2 databases of this host.
+func ParseIPRouteJSON(r io.Reader, kind NetRouteKind) (NetRouteList, error) {
+	return parseIPRouteJSON(r, kind, time.Now())
+}
+
+func parseIPRouteJSON(r io.Reader, kind NetRouteKind, now time.Time) (NetRouteList, error) {
+	var entries []jsonFields
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		if errors.Is(err, io.EOF) {
+			// ip prints nothing at all when no routes are found.
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	routes := make(NetRouteList, 0, len(entries))
+	for _, f := range entries {
+		route, err := parseIPRouteJSONEntry(f, kind, now)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func parseIPRouteJSONEntry(f jsonFields, kind NetRouteKind, now time.Time) (NetRoute, error) {
+	route := NetRoute{
+		Kind:     kind,
+		Type:     RouteTypeUnicast,
+		Table:    routeTableMain,
+		Netif:    f.string("dev"),
+		Gateway:  f.gateway(),
+		PrefSrc:  f.addr("prefsrc"),
+		HopFlags: f.hopFlags(),
+	}
+
+	if t := f.string("type"); t != "" {
+		idx := slices.Index(routeTypeNames[:], t)
+		if idx < 0 {
+			return NetRoute{}, &ErrInvalidRouteFileFormat{row: t}
+		}
+		route.Type = RouteType(idx)
+	}
+
+	var dst string
+	f.decode("dst", 0, &dst)
+	prefix, ok := ipRoutePrefix(dst, kind)
+	if !ok {
+		return NetRoute{}, &ErrInvalidRouteFileFormat{row: dst}
+	}
+	route.Dst = prefix
+	route.Destination = "default"
+	if prefix.Bits() != 0 {
+		route.Destination = prefix.String()
+	}
+	if from := f.string("from"); from != "" {
+		if src, ok := ipRoutePrefix(from, kind); ok && src.Bits() > 0 {
+			route.Src = src
+		}
+	}
+
+	if t := f.string("table"); t != "" {
+		if table, ok := parseRouteTable(t); ok {
+			route.Table = table
+		}
+	}
+	if p := f.string("protocol"); p != "" {
+		route.Protocol, _ = ParseRouteProtocol(p)
+	}
+	if s := f.string("scope"); s != "" {
+		route.Scope, _ = parseRouteScope(s)
+	}
+	route.Metric, _ = f.uint32("metric")
+	route.NextHopID, _ = f.uint32("nhid")
+	if secs, ok := f.uint32("expires"); ok {
+		route.ExpiresAt = now.Add(time.Duration(secs) * time.Second)
+	}
+
+	var metrics []jsonFields
+	if f.decode("metrics", 0, &metrics) && len(metrics) > 0 {
+		route.Metrics = parseIPRouteJSONMetrics(metrics[0])
+	}
+
+	// When encapsulated, the route's own "dst" comes first, followed by the
+	// one of the encapsulation.
+	route.Encap = parseIPRouteJSONEncap(f, 1)
+
+	var hops []jsonFields
+	f.decode("nexthops", 0, &hops)
+	for _, h := range hops {
+		weight, ok := h.uint32("weight")
+		if !ok {
+			weight = 1
+		}
+		route.NextHops = append(route.NextHops, NextHop{
+			Gateway: h.gateway(),
+			Netif:   h.string("dev"),
+			Weight:  int(weight),
+			Flags:   h.hopFlags(),
+			Encap:   parseIPRouteJSONEncap(h, 0),
+		})
+	}
+	if len(route.NextHops) > 0 {
+		route.Gateway, route.Netif = route.NextHops[0].Gateway, route.NextHops[0].Netif
+	}
+
+	route.Flags = routeFlagsFor(&route).String()
+	return route, nil
+}
+
+// ipRoutePrefix parses a destination as printed by ip: "default", a prefix,
+// or a single address.
+func ipRoutePrefix(s string, kind NetRouteKind) (netip.Prefix, bool) {
+	switch {
+	case s == "default" && kind == NetRouteKindV4:
+		return netip.PrefixFrom(netip.IPv4Unspecified(), 0), true
+	case s == "default":
+		return netip.PrefixFrom(netip.IPv6Unspecified(), 0), true
+	case strings.Contains(s, "/"):
+		p, err := netip.ParsePrefix(s)
+		return p, err == nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(a, a.BitLen()), true
+}
+
+// parseIPRouteJSONMetrics maps metrics printed by ip. Durations are printed
+// in milliseconds, and locks are not reported.
+func parseIPRouteJSONMetrics(f jsonFields) RouteMetrics {
+	u := func(key string) uint32 {
+		v, _ := f.uint32(key)
+		return v
+	}
+	ms := func(key string) time.Duration {
+		return time.Duration(u(key)) * time.Millisecond
+	}
+	return ipRouteMetrics(u, ms, f.string)
+}
+
+// ipRouteMetricNames maps the names ip prints metrics under.
+var ipRouteMetricNames = map[string]RouteMetric{
+	"mtu":                RouteMetricMTU,
+	"window":             RouteMetricWindow,
+	"rtt":                RouteMetricRTT,
+	"rttvar":             RouteMetricRTTVar,
+	"ssthresh":           RouteMetricSSThresh,
+	"cwnd":               RouteMetricCWND,
+	"advmss":             RouteMetricAdvMSS,
+	"reordering":         RouteMetricReordering,
+	"hoplimit":           RouteMetricHopLimit,
+	"initcwnd":           RouteMetricInitCWND,
+	"features":           RouteMetricFeatures,
+	"rto_min":            RouteMetricRTOMin,
+	"initrwnd":           RouteMetricInitRWND,
+	"quickack":           RouteMetricQuickAck,
+	"congctl":            RouteMetricCCAlgo,
+	"fastopen_no_cookie": RouteMetricFastOpenNoCookie,
+}
+
+// ipRouteMetrics builds RouteMetrics from values looked up by their names in
+// ipRouteMetricNames, through u, d and s for integers, durations and strings.
+func ipRouteMetrics(u func(string) uint32, d func(string) time.Duration, s func(string) string) RouteMetrics {
+	return RouteMetrics{
+		MTU:              u("mtu"),
+		Window:           u("window"),
+		RTT:              d("rtt"),
+		RTTVar:           d("rttvar"),
+		SSThresh:         u("ssthresh"),
+		CWND:             u("cwnd"),
+		AdvMSS:           u("advmss"),
+		Reordering:       u("reordering"),
+		HopLimit:         u("hoplimit"),
+		InitCWND:         u("initcwnd"),
+		Features:         u("features"),
+		RTOMin:           d("rto_min"),
+		InitRWND:         u("initrwnd"),
+		QuickAck:         u("quickack"),
+		CCAlgo:           s("congctl"),
+		FastOpenNoCookie: u("fastopen_no_cookie"),
+	}
+}
+
+// parseIPRouteJSONEncap maps the encapsulation printed by ip, whose "dst" is
+// the dstIdx-th one of the object.
+func parseIPRouteJSONEncap(f jsonFields, dstIdx int) *RouteEncap {
+	name := f.string("encap")
+	if name == "" {
+		return nil
+	}
+	idx := slices.Index(encapTypeNames[:], name)
+	if idx < 0 {
+		return nil
+	}
+	encap := &RouteEncap{Type: EncapType(idx)}
+
+	var dst string
+	f.decode("dst", dstIdx, &dst)
+	u8 := func(key string) uint8 {
+		v, _ := f.uint32(key)
+		return uint8(v)
+	}
+
+	switch encap.Type {
+	case EncapTypeMPLS:
+		for _, v := range strings.Split(dst, "/") {
+			if label, err := strconv.ParseUint(v, 10, 20); err == nil {
+				encap.Labels = append(encap.Labels, uint32(label))
+			}
+		}
+	case EncapTypeIP, EncapTypeIP6:
+		var id uint64
+		f.decode("id", 0, &id)
+		encap.TunnelID = id
+		encap.TunnelSrc = f.addr("src")
+		encap.TunnelDst, _ = netip.ParseAddr(dst)
+		if encap.Type == EncapTypeIP {
+			encap.TTL, encap.TOS = u8("ttl"), u8("tos")
+		} else {
+			encap.TTL, encap.TOS = u8("hoplimit"), u8("tc")
+		}
+	case EncapTypeSeg6:
+		if mode := slices.Index(seg6ModeNames[:], f.string("mode")); mode >= 0 {
+			encap.Seg6Mode = Seg6Mode(mode)
+		}
+		var segs []string
+		f.decode("segs", 0, &segs)
+		for _, v := range segs {
+			if a, err := netip.ParseAddr(v); err == nil {
+				encap.Segments = append(encap.Segments, a)
+			}
+		}
+	}
+	return encap
+}
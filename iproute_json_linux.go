@@ -0,0 +1,28 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+)
+
+// ipRouteJSONRoutes lists routes of all tables by running
+// `ip -j -d route show table all` for each family.
+func ipRouteJSONRoutes(ctx context.Context) (NetRouteList, error) {
+	var routes NetRouteList
+	for _, kind := range []NetRouteKind{NetRouteKindV4, NetRouteKindV6} {
+		family := "-4"
+		if kind == NetRouteKindV6 {
+			family = "-6"
+		}
+		output, err := runCommand(ctx, "ip", "-j", "-d", family, "route", "show", "table", "all")
+		if err != nil {
+			return nil, err
+		}
+		list, err := ParseIPRouteJSON(bytes.NewReader(output), kind)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, list...)
+	}
+	return routes, nil
+}
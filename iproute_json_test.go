@@ -0,0 +1,101 @@
+package gateway
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPRouteJSON(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	parse := func(t *testing.T, fixture string, kind NetRouteKind) NetRouteList {
+		t.Helper()
+		routes, err := parseIPRouteJSON(bytes.NewReader(fixtureFile(t, fixture)), kind, now)
+		require.NoError(t, err)
+		return routes
+	}
+
+	t.Run("IPv4", func(t *testing.T) {
+		routes := parse(t, "ipRouteJSON4", NetRouteKindV4)
+		require.Len(t, routes, 16)
+
+		custom := routes[0]
+		assert.Equal(t, uint32(100), custom.Table)
+		assert.Equal(t, "default", custom.Destination)
+		assert.Equal(t, "UG", custom.Flags)
+		assert.Equal(t, RouteProtocolStatic, custom.Protocol)
+		assert.Equal(t, netip.MustParseAddr("10.1.0.2"), custom.PrefSrc)
+		assert.Equal(t, uint32(1400), custom.Metrics.MTU)
+		assert.Equal(t, uint32(10), custom.Metrics.InitCWND)
+
+		defaults := routes.FindDefaults(NetRouteKindV4)
+		require.Len(t, defaults, 1)
+		assert.Equal(t, []NextHop{
+			{Gateway: "10.0.0.1", Netif: "d0", Weight: 2},
+			{Gateway: "10.1.0.1", Netif: "d1", Weight: 1},
+		}, defaults[0].NextHops)
+
+		grouped := routes[4]
+		assert.Equal(t, uint32(2), grouped.NextHopID)
+		assert.Len(t, grouped.NextHops, 2)
+
+		encap := routes[5]
+		assert.Equal(t, "172.21.0.0/16", encap.Destination)
+		require.NotNil(t, encap.Encap)
+		assert.Equal(t, EncapTypeIP, encap.Encap.Type)
+		assert.Equal(t, uint64(5), encap.Encap.TunnelID)
+		assert.Equal(t, netip.MustParseAddr("10.9.9.9"), encap.Encap.TunnelDst)
+
+		assert.Equal(t, RouteTypeBlackhole, routes[6].Type)
+		assert.Equal(t, RouteTypeUnreachable, routes[7].Type)
+		assert.Equal(t, uint32(50), routes[8].Metric)
+		assert.Equal(t, NextHopOnLink, routes[8].HopFlags)
+		assert.Equal(t, uint32(routeTableLocal), routes[9].Table)
+		assert.Equal(t, RouteScopeHost, routes[9].Scope)
+		assert.Equal(t, netip.MustParsePrefix("10.0.0.2/32"), routes[9].Dst)
+	})
+
+	t.Run("IPv6", func(t *testing.T) {
+		routes := parse(t, "ipRouteJSON6", NetRouteKindV6)
+		defaults := routes.FindDefaults(NetRouteKindV6)
+		require.Len(t, defaults, 2)
+		assert.Equal(t, netip.MustParsePrefix("2001:db8:1::/48"), defaults[0].Src)
+		assert.Equal(t, RouteProtocolRA, defaults[1].Protocol)
+		assert.Equal(t, now.Add(599*time.Second), defaults[1].ExpiresAt)
+
+		seg6 := routes[2]
+		require.NotNil(t, seg6.Encap)
+		assert.Equal(t, Seg6ModeEncap, seg6.Encap.Seg6Mode)
+		assert.Equal(t, []netip.Addr{
+			netip.MustParseAddr("2001:db8::10"),
+			netip.MustParseAddr("2001:db8::11"),
+		}, seg6.Encap.Segments)
+	})
+
+	t.Run("MPLS and via", func(t *testing.T) {
+		routes, err := ParseIPRouteJSON(strings.NewReader(`[{"dst":"198.51.100.0/24","encap":"mpls","dst":"100/200",`+
+			`"via":{"family":"inet6","host":"fe80::1"},"dev":"eth0","table":"main","flags":["linkdown"]}]`), NetRouteKindV4)
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+		assert.Equal(t, "198.51.100.0/24", routes[0].Destination)
+		assert.Equal(t, "fe80::1", routes[0].Gateway)
+		assert.Equal(t, []uint32{100, 200}, routes[0].Encap.Labels)
+		assert.Equal(t, NextHopLinkDown, routes[0].Hops()[0].Flags)
+	})
+
+	t.Run("Empty output", func(t *testing.T) {
+		routes, err := ParseIPRouteJSON(strings.NewReader(""), NetRouteKindV6)
+		require.NoError(t, err)
+		assert.Empty(t, routes)
+	})
+
+	t.Run("Bad data", func(t *testing.T) {
+		_, err := ParseIPRouteJSON(bytes.NewReader(fixtureFile(t, "randomData")), NetRouteKindV4)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,330 @@
+package gateway
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ip route show:
+default via 10.1.0.1 dev d1 table 100 proto static src 10.1.0.2 mtu lock 1400
+default
+	nexthop via 10.0.0.1 dev d0 weight 2
+	nexthop via 10.1.0.1 dev d1 weight 1
+10.0.0.0/24 dev d0 proto kernel scope link src 10.0.0.2
+172.21.0.0/16  encap ip id 5 src 0.0.0.0 dst 10.9.9.9 ttl 0 tos 0 via 10.0.0.1 dev d0
+local 10.0.0.2 dev d0 table local proto kernel scope host src 10.0.0.2
+
+Each route starts with an optional type and its destination, followed by
+keywords and their values. The type, the main table, the boot protocol and
+the global scope are left out, and so are keywords for unset values. Next
+hops of multipath routes follow on their own lines, or on the same one when
+using -oneline. BusyBox prints a subset of those keywords, using the same
+format.
+*/
+
+// ipRouteTextArgs lists keywords followed by a value which are otherwise
+// ignored, so that their value isn't mistaken for a keyword.
+var ipRouteTextArgs = map[string]bool{
+	"tos":           true,
+	"dsfield":       true,
+	"realm":         true,
+	"realms":        true,
+	"pref":          true,
+	"error":         true,
+	"ttl-propagate": true,
+}
+
+// ParseIPRoute parses the text output of `ip route show`, as printed by
+// iproute2 and BusyBox, for routes of the given family. Unknown keywords are
+// skipped. Routing table and protocol names are resolved through the
+// iproute2 databases of this host.
+func ParseIPRoute(r io.Reader, kind NetRouteKind) (NetRouteList, error) {
+	return parseIPRoute(r, kind, time.Now())
+}
+
+func parseIPRoute(r io.Reader, kind NetRouteKind, now time.Time) (NetRouteList, error) {
+	var routes NetRouteList
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		row := scanner.Text()
+		fields := strings.Fields(row)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "nexthop" {
+			// Next hop of the previous route
+			if len(routes) == 0 {
+				return nil, &ErrInvalidRouteFileFormat{row: row}
+			}
+			parseIPRouteKeywords(&routes[len(routes)-1], fields, now)
+			continue
+		}
+
+		route, ok := parseIPRouteLine(fields, kind, now)
+		if !ok {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		routes = append(routes, route)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range routes {
+		r := &routes[i]
+		if len(r.NextHops) > 0 {
+			r.Gateway, r.Netif = r.NextHops[0].Gateway, r.NextHops[0].Netif
+		}
+		r.Flags = routeFlagsFor(r).String()
+	}
+	return routes, nil
+}
+
+func parseIPRouteLine(fields []string, kind NetRouteKind, now time.Time) (NetRoute, bool) {
+	route := NetRoute{
+		Kind:     kind,
+		Type:     RouteTypeUnicast,
+		Table:    routeTableMain,
+		Protocol: RouteProtocolBoot,
+	}
+	if idx := slices.Index(routeTypeNames[:], fields[0]); idx >= 0 && len(fields) > 1 {
+		route.Type = RouteType(idx)
+		fields = fields[1:]
+	}
+
+	prefix, ok := ipRoutePrefix(fields[0], kind)
+	if !ok {
+		return NetRoute{}, false
+	}
+	route.Dst = prefix
+	route.Destination = "default"
+	if prefix.Bits() != 0 {
+		route.Destination = prefix.String()
+	}
+
+	parseIPRouteKeywords(&route, fields[1:], now)
+	return route, true
+}
+
+// ipRouteTokens walks through the fields of a route.
+type ipRouteTokens []string
+
+func (t *ipRouteTokens) next() string {
+	if len(*t) == 0 {
+		return ""
+	}
+	v := (*t)[0]
+	*t = (*t)[1:]
+	return v
+}
+
+func (t *ipRouteTokens) peek() string {
+	if len(*t) == 0 {
+		return ""
+	}
+	return (*t)[0]
+}
+
+// parseIPRouteKeywords applies keywords of fields to route. Keywords
+// following "nexthop" apply to a new next hop of the route instead, when
+// they describe one.
+func parseIPRouteKeywords(route *NetRoute, fields []string, now time.Time) {
+	tokens := ipRouteTokens(fields)
+	var hop *NextHop
+	metrics := map[string]string{}
+
+	for len(tokens) > 0 {
+		key := tokens.next()
+		if flag, ok := ipRouteHopFlags[key]; ok {
+			if hop != nil {
+				hop.Flags |= flag
+			} else {
+				route.HopFlags |= flag
+			}
+			continue
+		}
+		if metric, ok := ipRouteMetricNames[key]; ok {
+			if tokens.peek() == "lock" {
+				tokens.next()
+				route.Metrics.Locked |= 1 << metric
+			}
+			metrics[key] = tokens.next()
+			continue
+		}
+		if ipRouteTextArgs[key] {
+			tokens.next()
+			continue
+		}
+
+		switch key {
+		case "nexthop":
+			route.NextHops = append(route.NextHops, NextHop{Weight: 1})
+			hop = &route.NextHops[len(route.NextHops)-1]
+		case "via":
+			gw := tokens.next()
+			if gw == "inet" || gw == "inet6" {
+				// Next hop of a different family than the route.
+				gw = tokens.next()
+			}
+			if hop != nil {
+				hop.Gateway = gw
+			} else {
+				route.Gateway = gw
+			}
+		case "dev":
+			if hop != nil {
+				hop.Netif = tokens.next()
+			} else {
+				route.Netif = tokens.next()
+			}
+		case "weight":
+			w, err := strconv.Atoi(tokens.next())
+			if err == nil && hop != nil {
+				hop.Weight = w
+			}
+		case "encap":
+			encap := parseIPRouteTextEncap(&tokens)
+			if hop != nil {
+				hop.Encap = encap
+			} else {
+				route.Encap = encap
+			}
+		case "src":
+			route.PrefSrc, _ = netip.ParseAddr(tokens.next())
+		case "from":
+			if src, ok := ipRoutePrefix(tokens.next(), route.Kind); ok && src.Bits() > 0 {
+				route.Src = src
+			}
+		case "table":
+			if table, ok := parseRouteTable(tokens.next()); ok {
+				route.Table = table
+			}
+		case "proto":
+			if p, err := ParseRouteProtocol(tokens.next()); err == nil {
+				route.Protocol = p
+			}
+		case "scope":
+			if s, ok := parseRouteScope(tokens.next()); ok {
+				route.Scope = s
+			}
+		case "metric":
+			if m, err := strconv.ParseUint(tokens.next(), 10, 32); err == nil {
+				route.Metric = uint32(m)
+			}
+		case "nhid":
+			if id, err := strconv.ParseUint(tokens.next(), 10, 32); err == nil {
+				route.NextHopID = uint32(id)
+			}
+		case "expires":
+			secs, err := strconv.Atoi(strings.TrimSuffix(tokens.next(), "sec"))
+			if err == nil {
+				route.ExpiresAt = now.Add(time.Duration(secs) * time.Second)
+			}
+		}
+	}
+
+	if len(metrics) > 0 {
+		locked := route.Metrics.Locked
+		route.Metrics = ipRouteMetrics(
+			func(key string) uint32 {
+				v, _ := strconv.ParseUint(metrics[key], 0, 32)
+				return uint32(v)
+			},
+			func(key string) time.Duration {
+				// Printed as "12ms", or "1.5s" past a second.
+				d, _ := time.ParseDuration(metrics[key])
+				return d
+			},
+			func(key string) string {
+				return metrics[key]
+			},
+		)
+		route.Metrics.Locked = locked
+	}
+}
+
+// ipRouteTextEncapKeys lists the keywords describing an IP tunnel, which
+// immediately follow its type.
+var ipRouteTextEncapKeys = map[string]bool{
+	"id":       true,
+	"src":      true,
+	"dst":      true,
+	"ttl":      true,
+	"tos":      true,
+	"hoplimit": true,
+	"tc":       true,
+}
+
+// parseIPRouteTextEncap parses an encapsulation such as "mpls 100/200",
+// "ip id 5 src 0.0.0.0 dst 10.9.9.9 ttl 0 tos 0", or
+// "seg6 mode encap segs 2 [ 2001:db8::10 2001:db8::11 ]".
+func parseIPRouteTextEncap(tokens *ipRouteTokens) *RouteEncap {
+	idx := slices.Index(encapTypeNames[:], tokens.next())
+	if idx < 0 {
+		return nil
+	}
+	encap := &RouteEncap{Type: EncapType(idx)}
+
+	switch encap.Type {
+	case EncapTypeMPLS:
+		for _, v := range strings.Split(tokens.next(), "/") {
+			if label, err := strconv.ParseUint(v, 10, 20); err == nil {
+				encap.Labels = append(encap.Labels, uint32(label))
+			}
+		}
+		if tokens.peek() == "ttl" {
+			tokens.next()
+			tokens.next()
+		}
+	case EncapTypeIP, EncapTypeIP6:
+		for ipRouteTextEncapKeys[tokens.peek()] {
+			key, value := tokens.next(), tokens.next()
+			n, _ := strconv.ParseUint(value, 0, 64)
+			switch key {
+			case "id":
+				encap.TunnelID = n
+			case "src":
+				encap.TunnelSrc, _ = netip.ParseAddr(value)
+			case "dst":
+				encap.TunnelDst, _ = netip.ParseAddr(value)
+			case "ttl", "hoplimit":
+				encap.TTL = uint8(n)
+			case "tos", "tc":
+				encap.TOS = uint8(n)
+			}
+		}
+	case EncapTypeSeg6:
+		for {
+			switch tokens.peek() {
+			case "mode":
+				tokens.next()
+				if mode := slices.Index(seg6ModeNames[:], tokens.next()); mode >= 0 {
+					encap.Seg6Mode = Seg6Mode(mode)
+				}
+			case "segs":
+				tokens.next()
+				tokens.next() // count
+				if tokens.peek() != "[" {
+					continue
+				}
+				tokens.next()
+				for v := tokens.next(); v != "]" && v != ""; v = tokens.next() {
+					if a, err := netip.ParseAddr(v); err == nil {
+						encap.Segments = append(encap.Segments, a)
+					}
+				}
+			case "hmac":
+				tokens.next()
+				tokens.next()
+			default:
+				return encap
+			}
+		}
+	}
+	return encap
+}
@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+)
+
+// ipRouteTextRoutes lists routes of all tables by running
+// `ip route show table all` for each family, for versions of ip lacking JSON
+// output, such as BusyBox's.
+func ipRouteTextRoutes(ctx context.Context) (NetRouteList, error) {
+	var routes NetRouteList
+	for _, kind := range []NetRouteKind{NetRouteKindV4, NetRouteKindV6} {
+		family := "-4"
+		if kind == NetRouteKindV6 {
+			family = "-6"
+		}
+		output, err := runCommand(ctx, "ip", family, "route", "show", "table", "all")
+		if err != nil {
+			return nil, err
+		}
+		list, err := ParseIPRoute(bytes.NewReader(output), kind)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, list...)
+	}
+	return routes, nil
+}
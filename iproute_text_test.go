@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPRoute(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Matches JSON", func(t *testing.T) {
+		for _, v := range []struct {
+			name string
+			kind NetRouteKind
+		}{{"4", NetRouteKindV4}, {"6", NetRouteKindV6}} {
+			text, err := parseIPRoute(bytes.NewReader(fixtureFile(t, "ipRoute"+v.name)), v.kind, now)
+			require.NoError(t, err)
+			json, err := parseIPRouteJSON(bytes.NewReader(fixtureFile(t, "ipRouteJSON"+v.name)), v.kind, now)
+			require.NoError(t, err)
+
+			// JSON output doesn't report locked metrics.
+			assert.True(t, text[0].Metrics.IsLocked(RouteMetricMTU) || v.kind == NetRouteKindV6)
+			for i := range text {
+				text[i].Metrics.Locked = 0
+			}
+			assert.Equal(t, json, text)
+		}
+	})
+
+	t.Run("BusyBox", func(t *testing.T) {
+		routes, err := ParseIPRoute(strings.NewReader(
+			"default via 192.168.1.1 dev eth0 \n"+
+				"192.168.1.0/24 dev eth0 scope link  src 192.168.1.10 \n"+
+				"10.8.0.0/16 via 10.8.0.1 dev tun0  metric 5 onlink dead\n"), NetRouteKindV4)
+		require.NoError(t, err)
+		require.Len(t, routes, 3)
+
+		defaults := routes.FindDefaults(NetRouteKindV4)
+		require.Len(t, defaults, 1)
+		assert.Equal(t, "192.168.1.1", defaults[0].Gateway)
+		assert.Equal(t, "eth0", defaults[0].Netif)
+		assert.Equal(t, "UG", defaults[0].Flags)
+		assert.Equal(t, netip.MustParseAddr("192.168.1.10"), routes[1].PrefSrc)
+		assert.Equal(t, RouteScopeLink, routes[1].Scope)
+		assert.Equal(t, uint32(5), routes[2].Metric)
+		assert.Equal(t, NextHopOnLink|NextHopDead, routes[2].HopFlags)
+	})
+
+	t.Run("Oneline", func(t *testing.T) {
+		routes, err := ParseIPRoute(strings.NewReader(
+			`default proto static metric 10 \	nexthop via inet6 fe80::1 dev eth0 weight 3 \	nexthop encap mpls 100/200 via 10.0.0.1 dev eth1 weight 1 onlink`+"\n"+
+				"10.2.0.0/16 via 10.0.0.1 dev eth1 rtt 1.5s rto_min lock 200ms congctl bbr\n"), NetRouteKindV4)
+		require.NoError(t, err)
+		require.Len(t, routes, 2)
+
+		assert.Equal(t, RouteProtocolStatic, routes[0].Protocol)
+		assert.Equal(t, "fe80::1", routes[0].Gateway)
+		assert.Equal(t, []NextHop{
+			{Gateway: "fe80::1", Netif: "eth0", Weight: 3},
+			{Gateway: "10.0.0.1", Netif: "eth1", Weight: 1, Flags: NextHopOnLink, Encap: &RouteEncap{
+				Type:   EncapTypeMPLS,
+				Labels: []uint32{100, 200},
+			}},
+		}, routes[0].NextHops)
+
+		assert.Equal(t, 1500*time.Millisecond, routes[1].Metrics.RTT)
+		assert.Equal(t, 200*time.Millisecond, routes[1].Metrics.RTOMin)
+		assert.True(t, routes[1].Metrics.IsLocked(RouteMetricRTOMin))
+		assert.Equal(t, "bbr", routes[1].Metrics.CCAlgo)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParseIPRoute(strings.NewReader("\tnexthop via 10.0.0.1 dev eth0\n"), NetRouteKindV4)
+		var invalid *ErrInvalidRouteFileFormat
+		assert.ErrorAs(t, err, &invalid)
+
+		_, err = ParseIPRoute(strings.NewReader("Error: ipv4: FIB table does not exist.\n"), NetRouteKindV4)
+		assert.ErrorAs(t, err, &invalid)
+	})
+}
@@ -154,3 +154,21 @@ func (r routeTableFlag) String() string {
 	}
 	return val
 }
+
+// routeFlagsFor synthesizes flags for routes obtained from sources which
+// don't report them, following the kernel's logic for /proc/net/route.
+func routeFlagsFor(r *NetRoute) routeTableFlag {
+	flags := rtfUp
+	for _, h := range r.Hops() {
+		if h.Gateway != "" {
+			flags |= rtfGateway
+		}
+	}
+	if r.Dst.IsSingleIP() {
+		flags |= rtfHost
+	}
+	if r.Type.Rejects() {
+		flags |= rtfReject
+	}
+	return flags
+}
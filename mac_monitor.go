@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// MACChange describes a change of the MAC address of a default gateway
+// whose address and interface remained the same. This may indicate ARP or
+// NDP spoofing, or a router being replaced.
+type MACChange struct {
+	Gateway  netip.Addr
+	Netif    string
+	Previous net.HardwareAddr
+	Current  net.HardwareAddr
+	// Neighbor is the entry of the gateway holding the new address.
+	Neighbor Neighbor
+}
+
+// MACMonitorOption customizes how MonitorGatewayMACs reports changes.
+type MACMonitorOption func(*macMonitorOptions)
+
+type macMonitorOptions struct {
+	allowed        []net.HardwareAddr
+	virtualRouters bool
+	poll           []PollOption
+	defaults       []DefaultsOption
+}
+
+// AllowMACs suppresses changes to any of the provided addresses, such as
+// those of known routers.
+func AllowMACs(macs ...net.HardwareAddr) MACMonitorOption {
+	return func(o *macMonitorOptions) {
+		o.allowed = append(o.allowed, macs...)
+	}
+}
+
+// AllowVirtualRouterMACs suppresses changes to virtual MAC addresses of
+// first-hop redundancy protocols, VRRP and HSRP, which gateways take over
+// when failing over to a backup router.
+func AllowVirtualRouterMACs() MACMonitorOption {
+	return func(o *macMonitorOptions) {
+		o.virtualRouters = true
+	}
+}
+
+// WithMACPollOptions sets how often the neighbor table is read. Debouncing
+// doesn't apply.
+func WithMACPollOptions(opts ...PollOption) MACMonitorOption {
+	return func(o *macMonitorOptions) {
+		o.poll = append(o.poll, opts...)
+	}
+}
+
+// WithMACDefaultsOptions sets options used to find default routes, such as
+// InTable or ExcludeProtocols, so that gateways of another table or VRF can
+// be monitored.
+func WithMACDefaultsOptions(opts ...DefaultsOption) MACMonitorOption {
+	return func(o *macMonitorOptions) {
+		o.defaults = append(o.defaults, opts...)
+	}
+}
+
+func (o *macMonitorOptions) allows(mac net.HardwareAddr) bool {
+	if o.virtualRouters && isVirtualRouterMAC(mac) {
+		return true
+	}
+	for _, v := range o.allowed {
+		if bytes.Equal(v, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVirtualRouterMAC returns whether mac is a virtual router address of
+// VRRP (RFC 5798, 00:00:5e:00:01:XX and 00:00:5e:00:02:XX), HSRP version 1
+// (00:00:0c:07:ac:XX), HSRP version 2 (00:00:0c:9f:fX:XX), or HSRP for IPv6
+// (00:05:73:a0:0X:XX).
+func isVirtualRouterMAC(mac net.HardwareAddr) bool {
+	if len(mac) != 6 {
+		return false
+	}
+	switch {
+	case bytes.HasPrefix(mac, []byte{0x00, 0x00, 0x5e, 0x00}):
+		return mac[4] == 0x01 || mac[4] == 0x02
+	case bytes.HasPrefix(mac, []byte{0x00, 0x00, 0x0c, 0x07, 0xac}):
+		return true
+	case bytes.HasPrefix(mac, []byte{0x00, 0x00, 0x0c, 0x9f}):
+		return mac[4]&0xf0 == 0xf0
+	case bytes.HasPrefix(mac, []byte{0x00, 0x05, 0x73, 0xa0}):
+		return mac[4]&0xf0 == 0x00
+	}
+	return false
+}
+
+// MACMonitor delivers changes of gateway MAC addresses until its context is
+// done.
+type MACMonitor struct {
+	events chan MACChange
+	err    error
+}
+
+// Events returns the channel changes are delivered on. It is closed once the
+// monitor stops.
+func (m *MACMonitor) Events() <-chan MACChange {
+	return m.events
+}
+
+// Err returns the error which stopped the monitor, once Events is closed. It
+// is nil for monitors stopped by their context.
+func (m *MACMonitor) Err() error {
+	return m.err
+}
+
+// MonitorGatewayMACs is like Resolver.MonitorGatewayMACs, reading routes and
+// neighbors of the host.
+func MonitorGatewayMACs(ctx context.Context, opts ...MACMonitorOption) (*MACMonitor, error) {
+	return systemResolver().MonitorGatewayMACs(ctx, opts...)
+}
+
+// MonitorGatewayMACs returns a MACMonitor recording the MAC address of the
+// gateway of each default route, as found by FindDefaultGatewayNeighbors,
+// and reporting when it changes while the gateway address and interface stay
+// the same. The neighbor table is read periodically, using the options of
+// PollRoutes.
+//
+// Gateways lacking a resolved neighbor entry are skipped until they have
+// one. Bindings are kept once their gateway stops being a default, so that a
+// gateway coming back with another address is reported as well.
+//
+// Failing to read the neighbor table initially is reported right away.
+// Later failures are retried with backoff; the monitor stops once five
+// reads in a row failed, reporting the last error through Err.
+func (res *Resolver) MonitorGatewayMACs(ctx context.Context, opts ...MACMonitorOption) (*MACMonitor, error) {
+	o := &macMonitorOptions{}
+	for _, fn := range opts {
+		fn(o)
+	}
+	neighbors, err := res.FindDefaultGatewayNeighbors(o.defaults...)
+	if err != nil {
+		return nil, err
+	}
+	bindings := map[macBindingKey]net.HardwareAddr{}
+	o.update(bindings, neighbors)
+
+	m := &MACMonitor{events: make(chan MACChange)}
+	go m.monitor(ctx, res, o, bindings)
+	return m, nil
+}
+
+type macBindingKey struct {
+	netif   string
+	gateway netip.Addr
+}
+
+// update records the addresses of neighbors into bindings, returning
+// changes which aren't allowed.
+func (o *macMonitorOptions) update(bindings map[macBindingKey]net.HardwareAddr, neighbors []GatewayNeighbor) []MACChange {
+	var changes []MACChange
+	for _, gn := range neighbors {
+		if gn.Neighbor == nil || len(gn.Neighbor.HardwareAddr) == 0 {
+			continue
+		}
+		k := macBindingKey{netif: gn.Netif, gateway: gn.Gateway}
+		mac := gn.Neighbor.HardwareAddr
+		prev, ok := bindings[k]
+		bindings[k] = mac
+		if ok && !bytes.Equal(prev, mac) && !o.allows(mac) {
+			changes = append(changes, MACChange{
+				Gateway:  gn.Gateway,
+				Netif:    gn.Netif,
+				Previous: prev,
+				Current:  mac,
+				Neighbor: *gn.Neighbor,
+			})
+		}
+	}
+	return changes
+}
+
+func (m *MACMonitor) monitor(ctx context.Context, res *Resolver, o *macMonitorOptions, bindings map[macBindingKey]net.HardwareAddr) {
+	defer close(m.events)
+	po := newPollOptions(o.poll)
+	var backoff time.Duration
+	failures := 0
+	for {
+		if !po.sleep(ctx, po.wait(backoff)) {
+			return
+		}
+		neighbors, err := res.FindDefaultGatewayNeighbors(o.defaults...)
+		if err != nil {
+			if failures++; failures >= pollMaxFailures {
+				m.err = err
+				return
+			}
+			backoff = po.backoff(backoff)
+			continue
+		}
+		backoff, failures = 0, 0
+
+		for _, c := range o.update(bindings, neighbors) {
+			select {
+			case m.events <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
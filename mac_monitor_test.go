@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitorGatewayMACs(t *testing.T) {
+	gw := netip.MustParseAddr("10.0.0.1")
+	router := Neighbor{IP: gw, HardwareAddr: mac("52:54:00:12:34:56"), Netif: "eth0", State: NeighborReachable}
+
+	// The neighbor table is provided by the test, and read after each sleep.
+	var mu sync.Mutex
+	var table []Neighbor
+	var tableErr error
+	set := func(err error, neighbors ...Neighbor) {
+		mu.Lock()
+		defer mu.Unlock()
+		table, tableErr = neighbors, err
+	}
+	res := &Resolver{
+		routes: func() (NetRouteList, Provenance, error) {
+			return NetRouteList{
+				{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0"},
+				{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth1", Metric: 100},
+				{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.2.0.1", Netif: "eth2", Table: 100},
+			}, Provenance{}, nil
+		},
+		neighbors: func() ([]Neighbor, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return table, tableErr
+		},
+	}
+	start := func(t *testing.T, opts ...MACMonitorOption) (*MACMonitor, *fakeClock) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		clock := newFakeClock()
+		opts = append(opts, WithMACPollOptions(WithClock(clock), WithPollInterval(time.Minute)))
+		m, err := res.MonitorGatewayMACs(ctx, opts...)
+		require.NoError(t, err)
+		return m, clock
+	}
+	// read lets the monitor read the table once, so that it doesn't change
+	// under its feet.
+	read := func(t *testing.T, clock *fakeClock, err error, neighbors ...Neighbor) {
+		t.Helper()
+		<-clock.requests
+		set(err, neighbors...)
+		clock.Advance(time.Minute)
+	}
+	with := func(n Neighbor, addr string) Neighbor {
+		n.HardwareAddr = mac(addr)
+		return n
+	}
+
+	t.Run("Changes", func(t *testing.T) {
+		set(nil, router)
+		m, clock := start(t)
+
+		spoofed := with(router, "02:00:00:00:00:66")
+		read(t, clock, nil, spoofed)
+		assert.Equal(t, MACChange{
+			Gateway:  gw,
+			Netif:    "eth0",
+			Previous: router.HardwareAddr,
+			Current:  spoofed.HardwareAddr,
+			Neighbor: spoofed,
+		}, <-m.Events())
+
+		// Failures, unresolved entries, and the same gateway on another
+		// interface don't alter bindings.
+		read(t, clock, errors.New("unavailable"))
+		<-clock.requests
+		set(nil, Neighbor{IP: gw, Netif: "eth0", State: NeighborFailed}, with(router, "02:00:00:00:00:01"))
+		clock.Advance(2 * time.Minute)
+		other := with(router, "02:00:00:00:00:02")
+		other.Netif = "eth1"
+		read(t, clock, nil, other)
+
+		read(t, clock, nil, router)
+		assert.Equal(t, MACChange{
+			Gateway:  gw,
+			Netif:    "eth0",
+			Previous: spoofed.HardwareAddr,
+			Current:  router.HardwareAddr,
+			Neighbor: router,
+		}, <-m.Events())
+	})
+
+	t.Run("Allowlists", func(t *testing.T) {
+		set(nil, router)
+		m, clock := start(t, AllowVirtualRouterMACs(), AllowMACs(mac("52:54:00:12:34:57")))
+
+		for _, addr := range []string{
+			"00:00:5e:00:01:0a",
+			"00:00:5e:00:02:0a",
+			"00:00:0c:07:ac:01",
+			"00:00:0c:9f:f0:01",
+			"00:05:73:a0:00:01",
+			"52:54:00:12:34:57",
+		} {
+			read(t, clock, nil, with(router, addr))
+		}
+		read(t, clock, nil, with(router, "00:00:5e:00:03:0a"))
+		assert.Equal(t, mac("00:00:5e:00:03:0a"), (<-m.Events()).Current)
+	})
+
+	t.Run("Tables", func(t *testing.T) {
+		vrfRouter := Neighbor{IP: netip.MustParseAddr("10.2.0.1"), HardwareAddr: mac("52:54:00:12:34:58"), Netif: "eth2", State: NeighborReachable}
+		set(nil, router, vrfRouter)
+		m, clock := start(t, WithMACDefaultsOptions(InTable(100)))
+
+		// Only gateways of the requested table are monitored.
+		spoofed := with(vrfRouter, "02:00:00:00:00:66")
+		read(t, clock, nil, with(router, "02:00:00:00:00:66"), spoofed)
+		assert.Equal(t, MACChange{
+			Gateway:  vrfRouter.IP,
+			Netif:    "eth2",
+			Previous: vrfRouter.HardwareAddr,
+			Current:  spoofed.HardwareAddr,
+			Neighbor: spoofed,
+		}, <-m.Events())
+	})
+
+	t.Run("Persistent errors", func(t *testing.T) {
+		set(nil, router)
+		m, clock := start(t)
+
+		// Successful reads reset the count of failures.
+		unavailable := errors.New("unavailable")
+		for i := 0; i < pollMaxFailures-1; i++ {
+			read(t, clock, unavailable)
+		}
+		read(t, clock, nil, router)
+		for i := 0; i < pollMaxFailures; i++ {
+			read(t, clock, unavailable)
+		}
+		_, ok := <-m.Events()
+		assert.False(t, ok)
+		assert.Equal(t, unavailable, m.Err())
+	})
+
+	t.Run("Initial error", func(t *testing.T) {
+		set(errors.New("unavailable"))
+		_, err := res.MonitorGatewayMACs(context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,68 @@
+package gateway
+
+import (
+	"path/filepath"
+	"strconv"
+)
+
+// netnsRunDir is where `ip netns` keeps bind mounts of named namespaces.
+var netnsRunDir = "/run/netns"
+
+// Namespace identifies a Linux network namespace, either by the path of a
+// namespace file, or by an open file descriptor referring to one.
+type Namespace struct {
+	// Path is the namespace file, such as /run/netns/<name> or
+	// /proc/<pid>/ns/net.
+	Path string
+	// FD is an open namespace file descriptor, used when Path is empty. It
+	// is not closed by this package.
+	FD int
+}
+
+// NamespaceFromName returns the namespace created by `ip netns add <name>`.
+func NamespaceFromName(name string) Namespace {
+	return Namespace{Path: filepath.Join(netnsRunDir, name)}
+}
+
+// NamespaceFromPID returns the namespace of the process with the provided
+// PID.
+func NamespaceFromPID(pid int) Namespace {
+	return Namespace{Path: filepath.Join("/proc", strconv.Itoa(pid), "ns", "net")}
+}
+
+// NamespaceFromPath returns the namespace referred by a namespace file.
+func NamespaceFromPath(path string) Namespace {
+	return Namespace{Path: path}
+}
+
+// NamespaceFromFD returns the namespace referred by an open file descriptor.
+func NamespaceFromFD(fd int) Namespace {
+	return Namespace{FD: fd}
+}
+
+// FindDefaultRoutes returns all default routes of the namespace. See
+// FindDefaultRoutes. Routes are only read through rtnetlink, as other
+// sources would report the namespace of the process instead.
+func (ns Namespace) FindDefaultRoutes(opts ...DefaultsOption) (routes NetRouteList, err error) {
+	err = ns.Do(func() error {
+		routes, err = RouteChain{RouteSourceNetlink}.Resolver().FindDefaultRoutes(opts...)
+		return err
+	})
+	return
+}
+
+// NamespaceDefaults holds the default routes of a namespace found by
+// EnumerateNamespaces.
+type NamespaceDefaults struct {
+	Namespace Namespace
+	// Name is the name given by `ip netns`, if any.
+	Name string
+	// Inode is the inode number of the namespace, as shown by `lsns`.
+	Inode uint64
+	// PIDs lists processes running in the namespace.
+	PIDs []int
+	// Routes lists default routes of the namespace.
+	Routes NetRouteList
+	// Err holds the error found while reading routes of the namespace.
+	Err error
+}
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"syscall"
+)
+
+const cloneNewNet = 0x40000000
+
+func setns(fd uintptr) error {
+	if _, _, errno := syscall.RawSyscall(sysSetns, fd, cloneNewNet, 0); errno != 0 {
+		return os.NewSyscallError("setns", errno)
+	}
+	return nil
+}
+
+// Do runs fn with the calling goroutine's OS thread switched into the
+// namespace. fn runs on a dedicated, locked OS thread; goroutines started
+// by it will not run in the namespace.
+//
+// Routes are read through rtnetlink, which honours the thread's namespace.
+// File-based sources under /proc/net reflect the namespace of the process
+// instead, and must not be relied upon within fn.
+func (ns Namespace) Do(fn func() error) error {
+	if ns.Path == "" && ns.FD == 0 {
+		// Descriptor 0 is stdin, which a zero Namespace would refer to.
+		return &ErrInvalidNamespace{}
+	}
+	fd := uintptr(ns.FD)
+	if ns.Path != "" {
+		f, err := os.Open(ns.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fd = f.Fd()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+
+		orig, err := os.Open("/proc/thread-self/ns/net")
+		if err != nil {
+			runtime.UnlockOSThread()
+			errCh <- err
+			return
+		}
+		defer orig.Close()
+
+		if err = setns(fd); err != nil {
+			runtime.UnlockOSThread()
+			errCh <- err
+			return
+		}
+
+		fnErr := fn()
+
+		if err = setns(orig.Fd()); err != nil {
+			// Leave the thread locked: it is terminated once this goroutine
+			// exits, instead of being reused while in the wrong namespace.
+			errCh <- errors.Join(fnErr, err)
+			return
+		}
+		runtime.UnlockOSThread()
+		errCh <- fnErr
+	}()
+	return <-errCh
+}
+
+// EnumerateNamespaces finds all network namespaces of the host, both named
+// ones created by `ip netns` and those used by running processes, returning
+// the default routes of each one. Failing to read a single namespace does not
+// stop enumeration; its error is reported in NamespaceDefaults.Err.
+func EnumerateNamespaces(opts ...DefaultsOption) ([]NamespaceDefaults, error) {
+	type nsKey struct {
+		dev uint64
+		ino uint64
+	}
+	var result []NamespaceDefaults
+	seen := map[nsKey]int{}
+
+	add := func(path string) *NamespaceDefaults {
+		var st syscall.Stat_t
+		if err := syscall.Stat(path, &st); err != nil {
+			return nil
+		}
+		key := nsKey{dev: uint64(st.Dev), ino: st.Ino}
+		if idx, ok := seen[key]; ok {
+			return &result[idx]
+		}
+		seen[key] = len(result)
+		result = append(result, NamespaceDefaults{
+			Namespace: NamespaceFromPath(path),
+			Inode:     st.Ino,
+		})
+		return &result[len(result)-1]
+	}
+
+	named, err := os.ReadDir(netnsRunDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, v := range named {
+		if ns := add(filepath.Join(netnsRunDir, v.Name())); ns != nil && ns.Name == "" {
+			ns.Name = v.Name()
+		}
+	}
+
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, v := range procs {
+		if pid, err := strconv.Atoi(v.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+	for _, pid := range pids {
+		// Processes may exit, or belong to other users; skip those.
+		if ns := add(NamespaceFromPID(pid).Path); ns != nil {
+			ns.PIDs = append(ns.PIDs, pid)
+		}
+	}
+
+	for i := range result {
+		result[i].Routes, result[i].Err = result[i].Namespace.FindDefaultRoutes(opts...)
+	}
+	return result, nil
+}
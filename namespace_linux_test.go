@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startNamespace runs a process in a new network namespace having a single
+// default route, returning its PID.
+func startNamespace(t *testing.T) int {
+	t.Helper()
+	if _, err := exec.LookPath("unshare"); err != nil {
+		t.Skip("unshare is not available")
+	}
+	if _, err := exec.LookPath("ip"); err != nil {
+		t.Skip("ip is not available")
+	}
+
+	cmd := exec.Command("unshare", "--net", "sh", "-c",
+		"ip link set lo up && ip route add default via 10.9.0.1 dev lo onlink && echo ready && exec sleep 60")
+	out, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	if line, _ := bufio.NewReader(out).ReadString('\n'); line != "ready\n" {
+		t.Skip("cannot create network namespaces")
+	}
+	return cmd.Process.Pid
+}
+
+// runIP runs ip with each of the provided argument lists within ns, skipping
+// the test if any fails, such as when the kernel lacks support for a feature.
+func runIP(t *testing.T, ns Namespace, commands [][]string) {
+	t.Helper()
+	// Skipping from fn would exit the goroutine of Do, never returning.
+	var failure string
+	require.NoError(t, ns.Do(func() error {
+		for _, args := range commands {
+			if out, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+				failure = fmt.Sprintf("ip %v: %s", args, out)
+				break
+			}
+		}
+		return nil
+	}))
+	if failure != "" {
+		t.Skip(failure)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	pid := startNamespace(t)
+
+	routes, err := NamespaceFromPID(pid).FindDefaultRoutes()
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "10.9.0.1", routes[0].Gateway)
+	assert.Equal(t, "lo", routes[0].Netif)
+
+	// The calling thread must be back in its original namespace.
+	own, err := FindDefaultRoutes()
+	if err == nil {
+		for _, r := range own {
+			assert.NotEqual(t, "10.9.0.1", r.Gateway)
+		}
+	}
+}
+
+func TestZeroNamespace(t *testing.T) {
+	called := false
+	err := Namespace{}.Do(func() error {
+		called = true
+		return nil
+	})
+	var invalid *ErrInvalidNamespace
+	assert.ErrorAs(t, err, &invalid)
+	assert.False(t, called)
+
+	_, err = NamespaceFromFD(0).FindDefaultRoutes()
+	assert.ErrorAs(t, err, &invalid)
+}
+
+func TestNamespaceWithoutNetlink(t *testing.T) {
+	pid := startNamespace(t)
+	// Other sources report routes of the process' namespace, and must not
+	// be used as a fallback.
+	setRouteSource(t, RouteSourceNetlink, func(context.Context) (NetRouteList, error) { return nil, os.ErrPermission })
+	setRouteSource(t, RouteSourceProc, func(context.Context) (NetRouteList, error) {
+		return NetRouteList{{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0"}}, nil
+	})
+
+	_, err := NamespaceFromPID(pid).FindDefaultRoutes()
+	var noSource *ErrNoRouteSource
+	assert.ErrorAs(t, err, &noSource)
+	assert.ErrorIs(t, err, os.ErrPermission)
+}
+
+func TestEnumerateNamespaces(t *testing.T) {
+	pid := startNamespace(t)
+
+	namespaces, err := EnumerateNamespaces()
+	require.NoError(t, err)
+
+	var found *NamespaceDefaults
+	for i, ns := range namespaces {
+		for _, p := range ns.PIDs {
+			if p == pid {
+				found = &namespaces[i]
+			}
+		}
+	}
+	require.NotNil(t, found)
+	require.NoError(t, found.Err)
+	require.Len(t, found.Routes, 1)
+	assert.Equal(t, "10.9.0.1", found.Routes[0].Gateway)
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package gateway
+
+// Do runs fn within the namespace. Network namespaces are only supported on
+// Linux.
+func (ns Namespace) Do(func() error) error {
+	return &ErrNotImplemented{}
+}
+
+// EnumerateNamespaces finds all network namespaces of the host. Network
+// namespaces are only supported on Linux.
+func EnumerateNamespaces(...DefaultsOption) ([]NamespaceDefaults, error) {
+	return nil, &ErrNotImplemented{}
+}
@@ -0,0 +1,7 @@
+//go:build linux && !386 && !amd64
+
+package gateway
+
+import "syscall"
+
+const sysSetns = syscall.SYS_SETNS
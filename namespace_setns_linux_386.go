@@ -0,0 +1,5 @@
+package gateway
+
+// sysSetns is the number of the setns(2) syscall, which the syscall package
+// doesn't define on 386.
+const sysSetns = 346
@@ -0,0 +1,5 @@
+package gateway
+
+// sysSetns is the number of the setns(2) syscall, which the syscall package
+// doesn't define on amd64.
+const sysSetns = 308
@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// NeighborState represents the NUD_* state of a neighbor table entry, as
+// shown by `ip neigh`.
+type NeighborState uint16
+
+const (
+	NeighborIncomplete NeighborState = 0x01
+	NeighborReachable  NeighborState = 0x02
+	NeighborStale      NeighborState = 0x04
+	NeighborDelay      NeighborState = 0x08
+	NeighborProbe      NeighborState = 0x10
+	NeighborFailed     NeighborState = 0x20
+	NeighborNoARP      NeighborState = 0x40
+	NeighborPermanent  NeighborState = 0x80
+
+	// neighborValid matches states in which the link-layer address is
+	// known (NUD_VALID).
+	neighborValid = NeighborPermanent | NeighborNoARP | NeighborReachable |
+		NeighborProbe | NeighborStale | NeighborDelay
+)
+
+var neighborStateNames = []struct {
+	state NeighborState
+	name  string
+}{
+	{NeighborIncomplete, "INCOMPLETE"},
+	{NeighborReachable, "REACHABLE"},
+	{NeighborStale, "STALE"},
+	{NeighborDelay, "DELAY"},
+	{NeighborProbe, "PROBE"},
+	{NeighborFailed, "FAILED"},
+	{NeighborNoARP, "NOARP"},
+	{NeighborPermanent, "PERMANENT"},
+}
+
+// String returns the names of the states set, as printed by `ip neigh`,
+// such as "REACHABLE", or "NONE" if no state is set.
+func (s NeighborState) String() string {
+	var names []string
+	for _, v := range neighborStateNames {
+		if s&v.state != 0 {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, ",")
+}
+
+// Valid returns whether the link-layer address of the neighbor is known,
+// even though its reachability may not have been confirmed recently.
+func (s NeighborState) Valid() bool {
+	return s&neighborValid != 0
+}
+
+// Neighbor represents an entry of the ARP or NDP neighbor table.
+type Neighbor struct {
+	IP netip.Addr
+	// HardwareAddr is the link-layer address of the neighbor. It is nil
+	// while the neighbor is being resolved, or after resolution failed.
+	HardwareAddr net.HardwareAddr
+	Netif        string
+	State        NeighborState
+	// Router is set for IPv6 neighbors which advertised themselves as
+	// routers.
+	Router bool
+}
+
+// GatewayNeighbor associates a next hop of a default route with the entry
+// of its gateway in the neighbor table.
+type GatewayNeighbor struct {
+	Gateway netip.Addr
+	Netif   string
+	// Neighbor is the entry of the gateway, or nil when the table has none,
+	// such as when no traffic went through the gateway yet.
+	Neighbor *Neighbor
+}
+
+// FindDefaultGatewayNeighbors returns the neighbor table entry of the gateway
+// of each next hop of default routes, both IPv4 and IPv6, providing the
+// gateway's MAC address and reachability state.
+func FindDefaultGatewayNeighbors(opts ...DefaultsOption) ([]GatewayNeighbor, error) {
+	return systemResolver().FindDefaultGatewayNeighbors(opts...)
+}
+
+// FindDefaultGatewayNeighbors is like the package-level
+// FindDefaultGatewayNeighbors, using routes and neighbors provided by the
+// resolver.
+func (res *Resolver) FindDefaultGatewayNeighbors(opts ...DefaultsOption) ([]GatewayNeighbor, error) {
+	routes, err := res.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	hops, err := defaultHops(routes, opts)
+	if err != nil {
+		return nil, err
+	}
+	if res.neighbors == nil {
+		return nil, &ErrNotImplemented{}
+	}
+	neighbors, err := res.neighbors()
+	if err != nil {
+		return nil, err
+	}
+	return joinNeighbors(hops, neighbors), nil
+}
+
+// joinNeighbors finds the neighbor entry of the gateway of each hop. Zones
+// are ignored, as backends don't consistently report them for link-local
+// gateways; the interface identifies the link instead.
+func joinNeighbors(hops []NextHop, neighbors []Neighbor) []GatewayNeighbor {
+	var result []GatewayNeighbor
+	for _, h := range hops {
+		gw, err := netip.ParseAddr(h.Gateway)
+		if err != nil {
+			continue
+		}
+		gn := GatewayNeighbor{Gateway: gw, Netif: h.Netif}
+		for i := range neighbors {
+			n := &neighbors[i]
+			if n.Netif == h.Netif && n.IP.WithZone("").Unmap() == gw.WithZone("").Unmap() {
+				gn.Neighbor = n
+				break
+			}
+		}
+		result = append(result, gn)
+	}
+	return result
+}
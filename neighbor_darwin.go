@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+)
+
+// ListNeighbors returns the entries of the ARP and NDP neighbor tables, as
+// listed by `arp -an` and `ndp -an`. IPv6 neighbors are omitted when
+// `ndp -an` fails or prints something else than neighbors, such as on hosts
+// without IPv6.
+func ListNeighbors() ([]Neighbor, error) {
+	ctx := context.Background()
+	output, err := runCommand(ctx, "arp", "-an")
+	if err != nil {
+		return nil, err
+	}
+	neighbors, err := parseArpOutput(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = runCommand(ctx, "ndp", "-an")
+	if err != nil {
+		return neighbors, nil
+	}
+	v6, err := parseNdpOutput(bytes.NewReader(output))
+	if err != nil {
+		return neighbors, nil
+	}
+	return append(neighbors, v6...), nil
+}
@@ -0,0 +1,21 @@
+package gateway
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListNeighborsWithoutNDP(t *testing.T) {
+	// ndp is missing from the directories setCommand installs tools in.
+	setCommand(t, "arp", `while IFS= read -r line; do echo "$line"; done <<'EOF'
+`+string(fixtureFile(t, "darwinArp"))+"EOF\n")
+
+	expected, err := parseArpOutput(bytes.NewReader(fixtureFile(t, "darwinArp")))
+	require.NoError(t, err)
+	neighbors, err := ListNeighbors()
+	require.NoError(t, err)
+	assert.Equal(t, expected, neighbors)
+}
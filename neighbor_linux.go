@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"os"
+	"syscall"
+)
+
+const (
+	ndaDst    = 1
+	ndaLLAddr = 2
+
+	sizeofNdMsg = 12
+	// ntfRouter is set on IPv6 neighbors which are routers.
+	ntfRouter = 0x80
+)
+
+// ListNeighbors returns the entries of the ARP and NDP neighbor tables, as
+// listed by `ip neigh`, which leaves out addresses not requiring resolution,
+// such as multicast ones. When rtnetlink is unavailable, IPv4 neighbors are
+// read from /proc/net/arp instead.
+func ListNeighbors() ([]Neighbor, error) {
+	neighbors, err := netlinkNeighbors()
+	if err == nil {
+		return neighbors, nil
+	}
+	data, procErr := os.ReadFile("/proc/net/arp")
+	if procErr != nil {
+		return nil, err
+	}
+	return parseProcARP(bytes.NewReader(data))
+}
+
+func netlinkNeighbors() ([]Neighbor, error) {
+	msgs, err := netlinkDump(syscall.RTM_GETNEIGH, make([]byte, sizeofNdMsg))
+	if err != nil {
+		return nil, err
+	}
+	links, err := linkNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var neighbors []Neighbor
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWNEIGH {
+			continue
+		}
+		if n, ok := parseNetlinkNeighbor(m.Data, links); ok && n.State != NeighborNoARP {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors, nil
+}
+
+/* ndmsg:
++--------+-----+-----+---------+-------+-------+------+
+| family | pad | pad | ifindex | state | flags | type |
++--------+-----+-----+---------+-------+-------+------+
+    u8      u8   u16     s32      u16     u8     u8
+*/
+
+func parseNetlinkNeighbor(b []byte, links map[int]string) (Neighbor, bool) {
+	if len(b) < sizeofNdMsg || (b[0] != syscall.AF_INET && b[0] != syscall.AF_INET6) {
+		return Neighbor{}, false
+	}
+	attrs := parseNetlinkAttrs(b[sizeofNdMsg:])
+	ip, ok := netip.AddrFromSlice(attrs.get(ndaDst))
+	if !ok {
+		return Neighbor{}, false
+	}
+
+	n := Neighbor{
+		IP:     ip,
+		Netif:  links[int(int32(binary.NativeEndian.Uint32(b[4:8])))],
+		State:  NeighborState(binary.NativeEndian.Uint16(b[8:10])),
+		Router: b[10]&ntfRouter != 0,
+	}
+	if ll := attrs.get(ndaLLAddr); len(ll) > 0 {
+		n.HardwareAddr = net.HardwareAddr(append([]byte(nil), ll...))
+	}
+	return n, true
+}
@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListNeighbors(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"link", "add", "veth0", "type", "veth", "peer", "name", "veth1"},
+		{"link", "set", "veth0", "up"},
+		{"addr", "add", "10.0.0.2/24", "dev", "veth0"},
+		{"-6", "addr", "add", "2001:db8::2/64", "dev", "veth0", "nodad"},
+		{"neigh", "add", "10.0.0.1", "lladdr", "52:54:00:12:34:56", "dev", "veth0", "nud", "reachable"},
+		{"neigh", "add", "2001:db8::1", "lladdr", "52:54:00:12:34:57", "dev", "veth0", "nud", "stale", "router"},
+		{"neigh", "add", "2001:db8::5", "dev", "veth0", "nud", "failed"},
+		{"route", "replace", "default", "via", "10.0.0.1"},
+		{"-6", "route", "add", "default", "via", "2001:db8::1"},
+	})
+
+	var neighbors []Neighbor
+	var gateways []GatewayNeighbor
+	require.NoError(t, ns.Do(func() (err error) {
+		if neighbors, err = ListNeighbors(); err != nil {
+			return err
+		}
+		gateways, err = FindDefaultGatewayNeighbors()
+		return err
+	}))
+
+	router := Neighbor{IP: netip.MustParseAddr("10.0.0.1"), HardwareAddr: mac("52:54:00:12:34:56"), Netif: "veth0", State: NeighborReachable}
+	ra := Neighbor{IP: netip.MustParseAddr("2001:db8::1"), HardwareAddr: mac("52:54:00:12:34:57"), Netif: "veth0", State: NeighborStale, Router: true}
+	assert.ElementsMatch(t, []Neighbor{
+		router,
+		ra,
+		{IP: netip.MustParseAddr("2001:db8::5"), Netif: "veth0", State: NeighborFailed},
+	}, neighbors)
+	assert.Equal(t, []GatewayNeighbor{
+		{Gateway: router.IP, Netif: "veth0", Neighbor: &router},
+		{Gateway: ra.IP, Netif: "veth0", Neighbor: &ra},
+	}, gateways)
+}
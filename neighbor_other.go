@@ -0,0 +1,9 @@
+//go:build !(darwin || linux)
+
+package gateway
+
+// ListNeighbors returns the entries of the ARP and NDP neighbor tables.
+// Neighbor tables are only supported on Linux and darwin.
+func ListNeighbors() ([]Neighbor, error) {
+	return nil, &ErrNotImplemented{}
+}
@@ -0,0 +1,208 @@
+package gateway
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+/* /proc/net/arp:
+IP address       HW type     Flags       HW address            Mask     Device
+10.0.0.1         0x1         0x2         52:54:00:12:34:56     *        eth0
+10.0.0.9         0x1         0x0         00:00:00:00:00:00     *        eth0
+*/
+
+// ATF_* flags of /proc/net/arp.
+const (
+	atfCom  = 0x02
+	atfPerm = 0x04
+)
+
+// parseProcARP parses /proc/net/arp, which only lists IPv4 neighbors. The
+// kernel only reports whether the address of a neighbor is known, so valid
+// entries are reported as reachable, even though they may be stale.
+func parseProcARP(r io.Reader) ([]Neighbor, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, &ErrCantParse{}
+	}
+	fields := fieldSet(strings.Fields(strings.NewReplacer("IP address", "IP", "HW type", "Type", "HW address", "HWAddr").Replace(scanner.Text())))
+	ipIdx, flagsIdx, hwIdx, devIdx := fields.fieldIdx("IP"), fields.fieldIdx("Flags"), fields.fieldIdx("HWAddr"), fields.fieldIdx("Device")
+	if ipIdx == -1 || flagsIdx == -1 || hwIdx == -1 || devIdx == -1 {
+		return nil, &ErrCantParse{}
+	}
+
+	var neighbors []Neighbor
+	for scanner.Scan() {
+		row := scanner.Text()
+		line := strings.Fields(row)
+		if len(line) == 0 {
+			continue
+		}
+		if len(line) != len(fields) {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		ip, err := netip.ParseAddr(line[ipIdx])
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		flags, err := strconv.ParseUint(strings.TrimPrefix(line[flagsIdx], "0x"), 16, 32)
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+
+		n := Neighbor{IP: ip, Netif: line[devIdx]}
+		switch {
+		case flags&atfPerm != 0:
+			n.State = NeighborPermanent
+		case flags&atfCom != 0:
+			n.State = NeighborReachable
+		default:
+			n.State = NeighborIncomplete
+		}
+		if n.State.Valid() {
+			n.HardwareAddr = parseHardwareAddr(line[hwIdx])
+		}
+		neighbors = append(neighbors, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+// parseHardwareAddr parses link-layer addresses, including those printed by
+// BSD tools, which omit leading zeros of each octet (0:11:2:33:44:55). nil
+// is returned for invalid addresses.
+func parseHardwareAddr(v string) net.HardwareAddr {
+	octets := strings.Split(v, ":")
+	for i, o := range octets {
+		if len(o) == 1 {
+			octets[i] = "0" + o
+		}
+	}
+	addr, err := net.ParseMAC(strings.Join(octets, ":"))
+	if err != nil {
+		return nil
+	}
+	return addr
+}
+
+/* darwin, arp -an:
+? (192.168.1.1) at 0:11:22:33:44:55 on en0 ifscope [ethernet]
+? (192.168.1.20) at (incomplete) on en0 ifscope [ethernet]
+? (224.0.0.251) at 1:0:5e:0:0:fb on en0 ifscope permanent [ethernet]
+*/
+
+// parseArpOutput parses the output of darwin's `arp -an`. Entries being
+// resolved are reported as incomplete, static ones as permanent, and others
+// as reachable, since arp doesn't tell whether they were confirmed recently.
+func parseArpOutput(r io.Reader) ([]Neighbor, error) {
+	var neighbors []Neighbor
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		row := scanner.Text()
+		fields := strings.Fields(row)
+		if len(fields) == 0 {
+			continue
+		}
+		// ? (ip) at addr on netif ...
+		if len(fields) < 6 || fields[2] != "at" || fields[4] != "on" {
+			return nil, &ErrCantParse{}
+		}
+		ip, err := netip.ParseAddr(strings.Trim(fields[1], "()"))
+		if err != nil {
+			return nil, &ErrCantParse{}
+		}
+
+		n := Neighbor{IP: ip, Netif: fields[5], State: NeighborReachable}
+		for _, v := range fields[6:] {
+			if v == "permanent" {
+				n.State = NeighborPermanent
+			}
+		}
+		if fields[3] == "(incomplete)" {
+			n.State = NeighborIncomplete
+		} else {
+			n.HardwareAddr = parseHardwareAddr(fields[3])
+		}
+		neighbors = append(neighbors, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+/* darwin, ndp -an:
+Neighbor                        Linklayer Address  Netif Expire    St Flgs Prbs
+fe80::1%lo0                     (incomplete)         lo0 permanent R
+fe80::aede:48ff:fe00:1122%en0   ac:de:48:0:11:22     en0 23h59m58s S  R
+2001:db8::1                     (incomplete)         en0 expired   I     1
+*/
+
+// ndpStates maps state letters printed by ndp.
+var ndpStates = map[string]NeighborState{
+	"I": NeighborIncomplete,
+	"R": NeighborReachable,
+	"S": NeighborStale,
+	"D": NeighborDelay,
+	"P": NeighborProbe,
+}
+
+// parseNdpOutput parses the output of darwin's `ndp -an`.
+func parseNdpOutput(r io.Reader) ([]Neighbor, error) {
+	var neighbors []Neighbor
+	seenHeader := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		row := scanner.Text()
+		fields := strings.Fields(row)
+		if len(fields) == 0 {
+			continue
+		}
+		if !seenHeader {
+			if fields[0] != "Neighbor" {
+				return nil, &ErrCantParse{}
+			}
+			seenHeader = true
+			continue
+		}
+		if len(fields) < 5 {
+			return nil, &ErrCantParse{}
+		}
+		ip, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, &ErrCantParse{}
+		}
+
+		n := Neighbor{IP: ip, Netif: fields[2], State: ndpStates[fields[4]]}
+		if fields[3] == "permanent" {
+			n.State = NeighborPermanent
+		}
+		// Flags are absent for entries without any, leaving probes in their
+		// place.
+		if len(fields) > 5 && strings.ContainsRune(fields[5], 'R') {
+			n.Router = true
+		}
+		// Addresses of the loopback interface are permanent entries without
+		// a link-layer address.
+		if fields[1] != "(incomplete)" {
+			n.HardwareAddr = parseHardwareAddr(fields[1])
+		}
+		neighbors = append(neighbors, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !seenHeader {
+		return nil, &ErrCantParse{}
+	}
+	return neighbors, nil
+}
@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mac(v string) net.HardwareAddr {
+	addr, err := net.ParseMAC(v)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+func TestParseProcARP(t *testing.T) {
+	t.Run("Linux", func(t *testing.T) {
+		neighbors, err := parseProcARP(bytes.NewReader(fixtureFile(t, "procNetARP")))
+		require.NoError(t, err)
+		assert.Equal(t, []Neighbor{
+			{IP: netip.MustParseAddr("10.0.0.3"), HardwareAddr: mac("52:54:00:aa:bb:cc"), Netif: "veth0", State: NeighborPermanent},
+			{IP: netip.MustParseAddr("10.0.0.1"), HardwareAddr: mac("52:54:00:12:34:56"), Netif: "veth0", State: NeighborReachable},
+			{IP: netip.MustParseAddr("10.0.0.4"), Netif: "veth0", State: NeighborIncomplete},
+		}, neighbors)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := parseProcARP(strings.NewReader(""))
+		assert.ErrorIs(t, err, &ErrCantParse{})
+
+		_, err = parseProcARP(bytes.NewReader(fixtureFile(t, "randomData")))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseArpOutput(t *testing.T) {
+	neighbors, err := parseArpOutput(bytes.NewReader(fixtureFile(t, "darwinArp")))
+	require.NoError(t, err)
+	assert.Equal(t, []Neighbor{
+		{IP: netip.MustParseAddr("192.168.1.1"), HardwareAddr: mac("00:11:22:33:44:55"), Netif: "en0", State: NeighborReachable},
+		{IP: netip.MustParseAddr("192.168.1.20"), Netif: "en0", State: NeighborIncomplete},
+		{IP: netip.MustParseAddr("192.168.1.255"), HardwareAddr: mac("ff:ff:ff:ff:ff:ff"), Netif: "en0", State: NeighborReachable},
+		{IP: netip.MustParseAddr("224.0.0.251"), HardwareAddr: mac("01:00:5e:00:00:fb"), Netif: "en0", State: NeighborPermanent},
+	}, neighbors)
+
+	_, err = parseArpOutput(bytes.NewReader(fixtureFile(t, "randomData")))
+	assert.ErrorIs(t, err, &ErrCantParse{})
+}
+
+func TestParseNdpOutput(t *testing.T) {
+	neighbors, err := parseNdpOutput(bytes.NewReader(fixtureFile(t, "darwinNdp")))
+	require.NoError(t, err)
+	assert.Equal(t, []Neighbor{
+		{IP: netip.MustParseAddr("::1"), Netif: "lo0", State: NeighborPermanent},
+		{IP: netip.MustParseAddr("2001:db8::1"), HardwareAddr: mac("00:11:22:33:44:55"), Netif: "en0", State: NeighborStale, Router: true},
+		{IP: netip.MustParseAddr("2001:db8::20"), Netif: "en0", State: NeighborIncomplete},
+		{IP: netip.MustParseAddr("fe80::1%lo0"), Netif: "lo0", State: NeighborPermanent},
+		{IP: netip.MustParseAddr("fe80::211:22ff:fe33:4455%en0"), HardwareAddr: mac("00:11:22:33:44:55"), Netif: "en0", State: NeighborReachable, Router: true},
+		{IP: netip.MustParseAddr("fe80::1c2d:3e4f:5a6b:7c8d%en0"), HardwareAddr: mac("a0:b1:c2:d3:e4:f5"), Netif: "en0", State: NeighborPermanent},
+	}, neighbors)
+
+	_, err = parseNdpOutput(bytes.NewReader(fixtureFile(t, "randomData")))
+	assert.ErrorIs(t, err, &ErrCantParse{})
+}
+
+func TestNeighborState(t *testing.T) {
+	assert.Equal(t, "STALE", NeighborStale.String())
+	assert.Equal(t, "NONE", NeighborState(0).String())
+	assert.Equal(t, "REACHABLE,PERMANENT", (NeighborReachable | NeighborPermanent).String())
+	assert.True(t, NeighborDelay.Valid())
+	assert.False(t, NeighborFailed.Valid())
+	assert.False(t, NeighborIncomplete.Valid())
+}
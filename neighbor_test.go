@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDefaultGatewayNeighbors(t *testing.T) {
+	router := Neighbor{IP: netip.MustParseAddr("10.0.0.1"), HardwareAddr: mac("52:54:00:12:34:56"), Netif: "eth0", State: NeighborReachable}
+	ra := Neighbor{IP: netip.MustParseAddr("fe80::1%eth0"), HardwareAddr: mac("52:54:00:12:34:57"), Netif: "eth0", State: NeighborStale, Router: true}
+	res := &Resolver{
+		routes: func() (NetRouteList, Provenance, error) {
+			return NetRouteList{
+				{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0"},
+				{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth1", Metric: 100},
+				{Kind: NetRouteKindV4, Destination: "10.0.0.0", Flags: "U", Netif: "eth0"},
+				{Kind: NetRouteKindV6, Destination: "::/0", Flags: "UG", Gateway: "fe80::1", Netif: "eth0"},
+			}, Provenance{}, nil
+		},
+		neighbors: func() ([]Neighbor, error) {
+			return []Neighbor{
+				{IP: netip.MustParseAddr("10.0.0.2"), HardwareAddr: mac("52:54:00:00:00:02"), Netif: "eth0", State: NeighborReachable},
+				router,
+				ra,
+			}, nil
+		},
+	}
+
+	neighbors, err := res.FindDefaultGatewayNeighbors()
+	require.NoError(t, err)
+	assert.Equal(t, []GatewayNeighbor{
+		{Gateway: netip.MustParseAddr("10.0.0.1"), Netif: "eth0", Neighbor: &router},
+		// Neighbors of other interfaces don't match.
+		{Gateway: netip.MustParseAddr("10.0.0.1"), Netif: "eth1"},
+		{Gateway: netip.MustParseAddr("fe80::1"), Netif: "eth0", Neighbor: &ra},
+	}, neighbors)
+
+	res.neighbors = nil
+	_, err = res.FindDefaultGatewayNeighbors()
+	assert.ErrorIs(t, err, &ErrNotImplemented{})
+}
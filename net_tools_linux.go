@@ -0,0 +1,31 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+)
+
+// netToolsRoutes lists routes of the main table by running net-tools'
+// `netstat -rn`. IPv6 routes are omitted when `netstat -rn -A inet6` fails
+// or prints something else than routes, as BusyBox's netstat only supports
+// IPv4.
+func netToolsRoutes(ctx context.Context) (NetRouteList, error) {
+	output, err := runCommand(ctx, "netstat", "-rn")
+	if err != nil {
+		return nil, err
+	}
+	routes, err := ParseNetToolsRoutes(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = runCommand(ctx, "netstat", "-rn", "-A", "inet6")
+	if err != nil {
+		return routes, nil
+	}
+	ip6List, err := ParseNetToolsRoutes(bytes.NewReader(output))
+	if err != nil {
+		return routes, nil
+	}
+	return append(routes, ip6List...), nil
+}
@@ -0,0 +1,23 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetToolsRoutes(t *testing.T) {
+	// IPv4 routes are kept when those of IPv6 can't be parsed.
+	setCommand(t, "netstat", `if [ "$3" = inet6 ]; then echo "Kernel IPv6 routing table"; echo garbage; exit; fi
+while IFS= read -r line; do echo "$line"; done <<'EOF'
+`+string(fixtureFile(t, "netToolsNetstat"))+"EOF\n")
+
+	routes, err := netToolsRoutes(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, routes)
+	for _, r := range routes {
+		assert.Equal(t, NetRouteKindV4, r.Kind)
+	}
+}
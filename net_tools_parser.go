@@ -0,0 +1,254 @@
+package gateway
+
+import (
+	"bufio"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* route -n, route -A inet6 -n, netstat -rn:
+Kernel IP routing table
+Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
+0.0.0.0         10.0.0.1        0.0.0.0         UG    0      0        0 d0
+192.168.0.0     0.0.0.0         255.255.0.0     U     0      0        0 *
+192.169.0.0     -               255.255.0.0     !     0      -        0 -
+Kernel IPv6 routing table
+Destination                    Next Hop                   Flag Met Ref  Use If
+::/0                           2001:db8::1                UGe  1024 1      0 d0
+::/0                           ::                         !n   -1  2      0 lo
+
+net-tools renders /proc/net/route and /proc/net/ipv6_route, printing "-" in
+place of fields of rejecting routes. netstat -rn prints the MSS, Window and
+irtt columns instead of Metric, Ref and Use, unless -e is given. Without -n,
+addresses are replaced by names, such as "default" and "*".
+*/
+
+const (
+	ntDestination = "Destination"
+	ntGateway     = "Gateway"
+	ntNextHop     = "NextHop"
+	ntGenmask     = "Genmask"
+	ntFlags       = "Flags"
+	ntFlag        = "Flag"
+	ntMetric      = "Metric"
+	ntMet         = "Met"
+	ntRef         = "Ref"
+	ntUse         = "Use"
+	ntIface       = "Iface"
+	ntIf          = "If"
+	ntMSS         = "MSS"
+	ntWindow      = "Window"
+	ntIRTT        = "irtt"
+)
+
+// netToolsFlags4 and netToolsFlags6 map flag letters printed by net-tools
+// for each family.
+var (
+	netToolsFlags4 = map[rune]routeTableFlag{
+		'U': rtfUp,
+		'G': rtfGateway,
+		'H': rtfHost,
+		'R': rtfReinstate,
+		'D': rtfDynamic,
+		'M': rtfModified,
+		'!': rtfReject,
+	}
+	netToolsFlags6 = map[rune]routeTableFlag{
+		'U': rtfUp,
+		'G': rtfGateway,
+		'H': rtfHost,
+		'!': rtfReject,
+		'D': rtfDefault,
+		'A': rtfAddrConf,
+		'C': rtfCache,
+		'a': rtfAllOnLink,
+		'e': rtfExpires,
+		'm': rtfModified,
+		'n': rtfNoNextHop,
+		'f': rtfFlow,
+	}
+)
+
+// netToolsTable holds column indexes of the table being parsed.
+type netToolsTable struct {
+	kind                            NetRouteKind
+	dst, gateway, genmask, flags    int
+	metric, ref, use, iface         int
+	mss, window, irtt, columnsCount int
+}
+
+// ParseNetToolsRoutes parses the output of Linux's net-tools `route -n`,
+// `route -A inet6 -n` and `netstat -rn`, which may be concatenated. Sections
+// other than "Kernel IP routing table" and "Kernel IPv6 routing table", such
+// as the routing cache, are skipped.
+func ParseNetToolsRoutes(r io.Reader) (NetRouteList, error) {
+	var routes NetRouteList
+	var table *netToolsTable
+	var kind NetRouteKind
+	seenHeader := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		row := scanner.Text()
+		line := strings.TrimSpace(row)
+		switch strings.ToLower(line) {
+		case "":
+			continue
+		case "kernel ip routing table":
+			kind, table, seenHeader = NetRouteKindV4, nil, true
+			continue
+		case "kernel ipv6 routing table":
+			kind, table, seenHeader = NetRouteKindV6, nil, true
+			continue
+		}
+		if !seenHeader {
+			return nil, &ErrCantParse{}
+		}
+		if strings.HasPrefix(line, "Kernel ") {
+			// Another kind of table, such as the routing cache.
+			kind, table = 0, nil
+			continue
+		}
+		if kind == 0 {
+			continue
+		}
+
+		if table == nil {
+			t, ok := parseNetToolsHeader(line, kind)
+			if !ok {
+				return nil, &ErrInvalidRouteFileFormat{row: row}
+			}
+			table = t
+			continue
+		}
+
+		route, ok := table.parseRow(strings.Fields(line))
+		if !ok {
+			return nil, &ErrInvalidRouteFileFormat{row: row}
+		}
+		routes = append(routes, route)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !seenHeader {
+		return nil, &ErrCantParse{}
+	}
+	return routes, nil
+}
+
+func parseNetToolsHeader(line string, kind NetRouteKind) (*netToolsTable, bool) {
+	fields := fieldSet(strings.Fields(strings.Replace(line, "Next Hop", ntNextHop, 1)))
+	t := &netToolsTable{
+		kind:         kind,
+		dst:          fields.fieldIdx(ntDestination),
+		gateway:      fields.fieldIdx(ntGateway),
+		genmask:      fields.fieldIdx(ntGenmask),
+		flags:        fields.fieldIdx(ntFlags),
+		metric:       fields.fieldIdx(ntMetric),
+		ref:          fields.fieldIdx(ntRef),
+		use:          fields.fieldIdx(ntUse),
+		iface:        fields.fieldIdx(ntIface),
+		mss:          fields.fieldIdx(ntMSS),
+		window:       fields.fieldIdx(ntWindow),
+		irtt:         fields.fieldIdx(ntIRTT),
+		columnsCount: len(fields),
+	}
+	if kind == NetRouteKindV6 {
+		t.gateway, t.flags = fields.fieldIdx(ntNextHop), fields.fieldIdx(ntFlag)
+		t.metric, t.iface = fields.fieldIdx(ntMet), fields.fieldIdx(ntIf)
+	}
+
+	if t.dst == -1 || t.gateway == -1 || t.flags == -1 || t.iface == -1 {
+		return nil, false
+	}
+	if kind == NetRouteKindV4 && t.genmask == -1 {
+		return nil, false
+	}
+	return t, true
+}
+
+func (t *netToolsTable) parseRow(fields []string) (NetRoute, bool) {
+	if len(fields) != t.columnsCount {
+		return NetRoute{}, false
+	}
+
+	flagsTable := netToolsFlags4
+	if t.kind == NetRouteKindV6 {
+		flagsTable = netToolsFlags6
+	}
+	var flags routeTableFlag
+	for _, c := range fields[t.flags] {
+		flags |= flagsTable[c]
+	}
+
+	route := NetRoute{
+		Kind:    t.kind,
+		Flags:   flags.String(),
+		Netif:   fields[t.iface],
+		Gateway: netToolsAddr(fields[t.gateway], t.kind),
+		Metric:  uint32(netToolsInt(fields, t.metric)),
+		RefCnt:  uint32(netToolsInt(fields, t.ref)),
+		Use:     uint32(netToolsInt(fields, t.use)),
+	}
+
+	if t.kind == NetRouteKindV4 {
+		route.Type = procRouteTypeIPv4(flags, route.Netif)
+		route.Destination = netToolsAddr(fields[t.dst], t.kind)
+		dst, err := netip.ParseAddr(route.Destination)
+		mask, maskErr := netip.ParseAddr(netToolsAddr(fields[t.genmask], t.kind))
+		if err == nil && maskErr == nil {
+			route.Dst = prefixFromMask(dst, mask)
+		}
+
+		// MSS holds advmss+40, as the MTU column of /proc/net/route.
+		if v := netToolsInt(fields, t.mss); v > 40 {
+			route.Metrics.AdvMSS = uint32(v - 40)
+		}
+		route.Metrics.Window = uint32(netToolsInt(fields, t.window))
+		route.Metrics.RTT = time.Duration(netToolsInt(fields, t.irtt)) * time.Millisecond
+	} else {
+		route.Type = procRouteTypeIPv6(flags)
+		route.Destination = fields[t.dst]
+		if fields[t.dst] == "default" {
+			route.Destination = "::/0"
+		}
+		if dst, err := netip.ParsePrefix(route.Destination); err == nil {
+			route.Dst = dst
+		}
+	}
+	return route, true
+}
+
+// netToolsAddr maps names printed in place of unspecified addresses, or
+// fields of rejecting routes, to the unspecified address of the family.
+func netToolsAddr(v string, kind NetRouteKind) string {
+	switch v {
+	case "default", "*", "-":
+		if kind == NetRouteKindV4 {
+			return "0.0.0.0"
+		}
+		return "::"
+	}
+	return v
+}
+
+// netToolsInt returns the value of fields[idx], or zero in case the column
+// is absent, or holds "-". Metrics of rejecting IPv6 routes are printed as
+// signed values.
+func netToolsInt(fields []string, idx int) int64 {
+	if idx < 0 || idx >= len(fields) {
+		return 0
+	}
+	v, err := strconv.ParseInt(fields[idx], 10, 64)
+	if err != nil {
+		return 0
+	}
+	if v < 0 {
+		return int64(uint32(int32(v)))
+	}
+	return v
+}
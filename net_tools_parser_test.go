@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetToolsRoutes(t *testing.T) {
+	t.Run("route", func(t *testing.T) {
+		routes, err := ParseNetToolsRoutes(bytes.NewReader(fixtureFile(t, "netToolsRoute")))
+		require.NoError(t, err)
+		require.Len(t, routes, 23)
+
+		defaults := routes.FindDefaults(NetRouteKindV4)
+		require.Len(t, defaults, 2)
+		assert.Equal(t, "10.0.0.9", defaults[1].Gateway)
+		assert.Equal(t, uint32(100), defaults[1].Metric)
+		assert.Equal(t, netip.MustParsePrefix("0.0.0.0/0"), defaults[1].Dst)
+
+		assert.Equal(t, netip.MustParsePrefix("172.16.0.0/12"), routes[4].Dst)
+		// Blackhole and throw routes are listed the same way.
+		assert.Equal(t, RouteTypeUnspec, routes[6].Type)
+		assert.Equal(t, RouteTypeUnreachable, routes[7].Type)
+
+		defaults = routes.FindDefaults(NetRouteKindV6)
+		require.Len(t, defaults, 2)
+		assert.Equal(t, "2001:db8::1", defaults[0].Gateway)
+		assert.Equal(t, uint32(1024), defaults[0].Metric)
+		assert.Equal(t, uint32(1), defaults[0].RefCnt)
+		assert.Equal(t, "UGe", defaults[1].Flags)
+
+		reject := routes[len(routes)-1]
+		assert.Equal(t, RouteTypeUnreachable, reject.Type)
+		assert.Equal(t, uint32(0xffffffff), reject.Metric)
+	})
+
+	t.Run("netstat", func(t *testing.T) {
+		routes, err := ParseNetToolsRoutes(bytes.NewReader(fixtureFile(t, "netToolsNetstat")))
+		require.NoError(t, err)
+		require.Len(t, routes, 8)
+
+		defaults := routes.FindDefaults(NetRouteKindV4)
+		require.Len(t, defaults, 2)
+		assert.Equal(t, RouteMetrics{
+			AdvMSS: 1400,
+			Window: 4096,
+			RTT:    30 * time.Millisecond,
+		}, defaults[1].Metrics)
+	})
+
+	t.Run("Names", func(t *testing.T) {
+		routes, err := ParseNetToolsRoutes(strings.NewReader(
+			"Kernel IP routing table\n" +
+				"Destination     Gateway         Genmask         Flags Metric Ref    Use Iface\n" +
+				"default         _gateway        0.0.0.0         UG    600    0        0 wlan0\n" +
+				"192.168.8.0     *               255.255.255.0   U     600    0        0 wlan0\n" +
+				"Kernel IP routing cache\n" +
+				"Source          Destination     Gateway         Flags Metric Ref    Use Iface\n" +
+				"192.168.8.105   1.1.1.1         _gateway              0      0        2 wlan0\n"))
+		require.NoError(t, err)
+		require.Len(t, routes, 2)
+		assert.Equal(t, "0.0.0.0", routes[0].Destination)
+		assert.Equal(t, "_gateway", routes[0].Gateway)
+		assert.Equal(t, "0.0.0.0", routes[1].Gateway)
+		assert.Equal(t, netip.MustParsePrefix("192.168.8.0/24"), routes[1].Dst)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var cantParse *ErrCantParse
+		_, err := ParseNetToolsRoutes(bytes.NewReader(fixtureFile(t, "darwin")))
+		assert.ErrorAs(t, err, &cantParse)
+
+		var invalid *ErrInvalidRouteFileFormat
+		_, err = ParseNetToolsRoutes(strings.NewReader(
+			"Kernel IP routing table\n" +
+				"Destination     Gateway         Genmask         Flags Metric Ref    Use Iface\n" +
+				"0.0.0.0         10.0.0.1\n"))
+		assert.ErrorAs(t, err, &invalid)
+	})
+}
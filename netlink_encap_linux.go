@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"slices"
+)
+
+/* Keep this in sync with /usr/src/linux/include/uapi/linux/lwtunnel.h,
+   mpls_iptunnel.h and seg6_iptunnel.h */
+
+const (
+	mplsIPTunnelDst = 1
+
+	lwtunnelIPID  = 1
+	lwtunnelIPDst = 2
+	lwtunnelIPSrc = 3
+	lwtunnelIPTTL = 4
+	lwtunnelIPTOS = 5
+
+	seg6IPTunnelSRH = 1
+)
+
+// parseNetlinkEncap decodes RTA_ENCAP_TYPE and RTA_ENCAP attributes,
+// returning nil when the route is not encapsulated.
+func parseNetlinkEncap(attrs netlinkAttrs) *RouteEncap {
+	return decodeNetlinkEncap(attrs.get(rtaEncapType), attrs.get(rtaEncap))
+}
+
+// decodeNetlinkEncap decodes an encapsulation type and its nested
+// attributes, which routes and nexthop objects carry in different
+// attributes.
+func decodeNetlinkEncap(rawType, rawEncap []byte) *RouteEncap {
+	if len(rawType) < 2 {
+		return nil
+	}
+	encap := &RouteEncap{Type: EncapType(binary.NativeEndian.Uint16(rawType))}
+	if encap.Type == EncapTypeNone {
+		return nil
+	}
+
+	nested := parseNetlinkAttrs(rawEncap)
+	switch encap.Type {
+	case EncapTypeMPLS:
+		// Label stack entries are in network byte order:
+		// label (20 bits) | traffic class (3) | bottom of stack (1) | ttl (8)
+		stack := nested.get(mplsIPTunnelDst)
+		for len(stack) >= 4 {
+			encap.Labels = append(encap.Labels, binary.BigEndian.Uint32(stack)>>12)
+			stack = stack[4:]
+		}
+	case EncapTypeIP, EncapTypeIP6:
+		if v := nested.get(lwtunnelIPID); len(v) >= 8 {
+			encap.TunnelID = binary.BigEndian.Uint64(v)
+		}
+		if v, ok := netip.AddrFromSlice(nested.get(lwtunnelIPDst)); ok {
+			encap.TunnelDst = v
+		}
+		if v, ok := netip.AddrFromSlice(nested.get(lwtunnelIPSrc)); ok {
+			encap.TunnelSrc = v
+		}
+		if v := nested.get(lwtunnelIPTTL); len(v) >= 1 {
+			encap.TTL = v[0]
+		}
+		if v := nested.get(lwtunnelIPTOS); len(v) >= 1 {
+			encap.TOS = v[0]
+		}
+	case EncapTypeSeg6:
+		encap.Seg6Mode, encap.Segments = parseSeg6Encap(nested.get(seg6IPTunnelSRH))
+	}
+	return encap
+}
+
+/* seg6_iptunnel_encap:
++------+---------+--------+------+---------------+---------------+-------+-----+------------+
+| mode | nexthdr | hdrlen | type | segments_left | first_segment | flags | tag | segments[] |
++------+---------+--------+------+---------------+---------------+-------+-----+------------+
+  i32      u8        u8      u8         u8              u8          u8     u16   16 bytes each
+*/
+
+// parseSeg6Encap decodes the mode and segment routing header of a SEG6
+// encapsulation. Segments are stored in reverse order in the header, and
+// are returned in the order they are visited.
+func parseSeg6Encap(b []byte) (Seg6Mode, []netip.Addr) {
+	const srhOffset, segmentsOffset = 4, 12
+	if len(b) < segmentsOffset {
+		return 0, nil
+	}
+	mode := Seg6Mode(int32(binary.NativeEndian.Uint32(b[0:4])))
+	count := int(b[srhOffset+4]) + 1
+
+	var segments []netip.Addr
+	raw := b[segmentsOffset:]
+	for i := 0; i < count && len(raw) >= 16; i++ {
+		segments = append(segments, netip.AddrFrom16([16]byte(raw[:16])))
+		raw = raw[16:]
+	}
+	slices.Reverse(segments)
+	return mode, segments
+}
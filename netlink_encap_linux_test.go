@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNetlinkAttr(typ uint16, value []byte) []byte {
+	b := make([]byte, netlinkAlign(4+len(value)))
+	binary.NativeEndian.PutUint16(b[0:2], uint16(4+len(value)))
+	binary.NativeEndian.PutUint16(b[2:4], typ)
+	copy(b[4:], value)
+	return b
+}
+
+func TestParseNetlinkEncap(t *testing.T) {
+	t.Run("MPLS", func(t *testing.T) {
+		encapType := binary.NativeEndian.AppendUint16(nil, uint16(EncapTypeMPLS))
+		stack := binary.BigEndian.AppendUint32(nil, 100<<12)
+		stack = binary.BigEndian.AppendUint32(stack, 200<<12|1<<8)
+		attrs := parseNetlinkAttrs(append(
+			testNetlinkAttr(rtaEncapType, encapType),
+			testNetlinkAttr(rtaEncap, testNetlinkAttr(mplsIPTunnelDst, stack))...,
+		))
+
+		encap := parseNetlinkEncap(attrs)
+		require.NotNil(t, encap)
+		assert.Equal(t, EncapTypeMPLS, encap.Type)
+		assert.Equal(t, []uint32{100, 200}, encap.Labels)
+	})
+
+	t.Run("No encapsulation", func(t *testing.T) {
+		assert.Nil(t, parseNetlinkEncap(nil))
+	})
+
+	t.Run("SRv6", func(t *testing.T) {
+		srh := binary.NativeEndian.AppendUint32(nil, uint32(Seg6ModeEncap))
+		srh = append(srh, 0, 4, 4, 1, 1, 0, 0, 0)
+		last := netip.MustParseAddr("2001:db8::11").As16()
+		first := netip.MustParseAddr("2001:db8::10").As16()
+		srh = append(append(srh, last[:]...), first[:]...)
+
+		mode, segments := parseSeg6Encap(srh)
+		assert.Equal(t, Seg6ModeEncap, mode)
+		assert.Equal(t, []netip.Addr{
+			netip.MustParseAddr("2001:db8::10"),
+			netip.MustParseAddr("2001:db8::11"),
+		}, segments)
+	})
+}
@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"os"
+	"syscall"
+)
+
+// netlinkAttr represents a single rtattr/nlattr contained in a rtnetlink
+// message payload.
+type netlinkAttr struct {
+	Type  uint16
+	Value []byte
+}
+
+type netlinkAttrs []netlinkAttr
+
+// get returns the value of the first attribute of the given type, or nil in
+// case it is not present.
+func (a netlinkAttrs) get(typ uint16) []byte {
+	for _, v := range a {
+		if v.Type == typ {
+			return v.Value
+		}
+	}
+	return nil
+}
+
+func (a netlinkAttrs) uint32(typ uint16) (uint32, bool) {
+	v := a.get(typ)
+	if len(v) < 4 {
+		return 0, false
+	}
+	return binary.NativeEndian.Uint32(v), true
+}
+
+func (a netlinkAttrs) string(typ uint16) string {
+	return netlinkString(a.get(typ))
+}
+
+// netlinkString decodes a NUL-terminated string attribute value.
+func netlinkString(v []byte) string {
+	for i, c := range v {
+		if c == 0 {
+			return string(v[:i])
+		}
+	}
+	return string(v)
+}
+
+func netlinkAlign(n int) int {
+	return (n + syscall.NLA_ALIGNTO - 1) & ^(syscall.NLA_ALIGNTO - 1)
+}
+
+// parseNetlinkAttrs parses a sequence of attributes. Malformed trailing data
+// is ignored, as the kernel guarantees well-formed payloads and userspace
+// can't do anything useful with a truncated attribute anyway.
+func parseNetlinkAttrs(b []byte) netlinkAttrs {
+	var attrs netlinkAttrs
+	for len(b) >= syscall.NLA_HDRLEN {
+		l := int(binary.NativeEndian.Uint16(b[0:2]))
+		t := binary.NativeEndian.Uint16(b[2:4])
+		if l < syscall.NLA_HDRLEN || l > len(b) {
+			break
+		}
+		attrs = append(attrs, netlinkAttr{
+			Type:  t &^ (syscall.NLA_F_NESTED | syscall.NLA_F_NET_BYTEORDER),
+			Value: b[syscall.NLA_HDRLEN:l],
+		})
+		if a := netlinkAlign(l); a < len(b) {
+			b = b[a:]
+		} else {
+			break
+		}
+	}
+	return attrs
+}
+
+// netlinkDump issues a dump request of the provided type over a new
+// NETLINK_ROUTE socket, returning all messages received until NLMSG_DONE.
+// header is the family-specific header (rtmsg, fib_rule_hdr, nhmsg, ...)
+// appended to the request.
+func netlinkDump(msgType uint16, header []byte) ([]syscall.NetlinkMessage, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	defer syscall.Close(fd)
+
+	sa := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}
+	if err = syscall.Bind(fd, sa); err != nil {
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	const seq = 1
+	req := make([]byte, syscall.NLMSG_HDRLEN+netlinkAlign(len(header)))
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], msgType)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], seq)
+	copy(req[syscall.NLMSG_HDRLEN:], header)
+
+	if err = syscall.Sendto(fd, req, 0, sa); err != nil {
+		return nil, os.NewSyscallError("sendto", err)
+	}
+
+	lsa, err := syscall.Getsockname(fd)
+	if err != nil {
+		return nil, os.NewSyscallError("getsockname", err)
+	}
+	pid := lsa.(*syscall.SockaddrNetlink).Pid
+
+	var result []syscall.NetlinkMessage
+	buf := make([]byte, 1<<16)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, os.NewSyscallError("recvfrom", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq || m.Header.Pid != pid {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return result, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) < 4 {
+					return nil, syscall.EINVAL
+				}
+				errno := -int32(binary.NativeEndian.Uint32(m.Data[0:4]))
+				if errno == 0 {
+					continue
+				}
+				return nil, os.NewSyscallError("netlink", syscall.Errno(errno))
+			}
+			// Message data is a slice of buf, which is reused by the next
+			// read.
+			m.Data = append([]byte(nil), m.Data...)
+			result = append(result, m)
+		}
+	}
+}
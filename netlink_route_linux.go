@@ -0,0 +1,280 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const (
+	// rtaVia carries a gateway of a different family than the route itself
+	// (e.g. an IPv4 route via an IPv6 next hop).
+	rtaVia = 18
+	// rtaEncapType and rtaEncap describe lightweight tunnel encapsulation.
+	rtaEncapType = 21
+	rtaEncap     = 22
+	// rtaNHID refers to a nexthop object.
+	rtaNHID = 30
+)
+
+// netlinkRoutes dumps routes of all tables and families through rtnetlink.
+func netlinkRoutes() (NetRouteList, error) {
+	msgs, err := netlinkDump(syscall.RTM_GETROUTE, make([]byte, syscall.SizeofRtMsg))
+	if err != nil {
+		return nil, err
+	}
+	links, err := linkNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var routes NetRouteList
+	usesObjects := false
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWROUTE {
+			continue
+		}
+		if r, ok := parseNetlinkRoute(m.Data, links); ok {
+			routes = append(routes, r)
+			usesObjects = usesObjects || r.NextHopID != 0
+		}
+	}
+
+	if usesObjects {
+		// Failing to list objects is not fatal, as the kernel also reports
+		// next hop information inline for most routes using them.
+		if objects, err := netlinkNextHops(links); err == nil {
+			routes.resolveNextHops(objects)
+		}
+	}
+	return routes, nil
+}
+
+// linkNames maps interface indexes to their names.
+func linkNames() (map[int]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(ifaces))
+	for _, v := range ifaces {
+		names[v.Index] = v.Name
+	}
+	return names, nil
+}
+
+/* rtmsg:
++--------+---------+---------+-----+-------+----------+-------+------+-------+
+| family | dst_len | src_len | tos | table | protocol | scope | type | flags |
++--------+---------+---------+-----+-------+----------+-------+------+-------+
+    u8       u8        u8      u8     u8       u8       u8      u8     u32
+*/
+
+func parseNetlinkRoute(b []byte, links map[int]string) (NetRoute, bool) {
+	if len(b) < syscall.SizeofRtMsg {
+		return NetRoute{}, false
+	}
+
+	var kind NetRouteKind
+	var unspecified netip.Addr
+	switch b[0] {
+	case syscall.AF_INET:
+		kind, unspecified = NetRouteKindV4, netip.IPv4Unspecified()
+	case syscall.AF_INET6:
+		kind, unspecified = NetRouteKindV6, netip.IPv6Unspecified()
+	default:
+		return NetRoute{}, false
+	}
+
+	attrs := parseNetlinkAttrs(b[syscall.SizeofRtMsg:])
+	route := NetRoute{
+		Kind:     kind,
+		Type:     RouteType(b[7]),
+		TOS:      b[3],
+		Table:    uint32(b[4]),
+		Protocol: RouteProtocol(b[5]),
+		Scope:    RouteScope(b[6]),
+		// RTNH_F_* flags are held by the lowest byte of rtm_flags.
+		HopFlags: NextHopFlags(binary.NativeEndian.Uint32(b[8:12])),
+	}
+	if t, ok := attrs.uint32(syscall.RTA_TABLE); ok {
+		route.Table = t
+	}
+	if m, ok := attrs.uint32(syscall.RTA_PRIORITY); ok {
+		route.Metric = m
+	}
+	if src, ok := netip.AddrFromSlice(attrs.get(syscall.RTA_PREFSRC)); ok {
+		route.PrefSrc = src
+	}
+	if m := attrs.get(syscall.RTA_METRICS); m != nil {
+		route.Metrics = parseNetlinkMetrics(m)
+	}
+	if oif, ok := attrs.uint32(syscall.RTA_OIF); ok {
+		route.Netif = links[int(oif)]
+	}
+
+	dst := unspecified
+	if v, ok := netip.AddrFromSlice(attrs.get(syscall.RTA_DST)); ok {
+		dst = v
+	}
+	route.Dst = netip.PrefixFrom(dst, int(b[1]))
+	if route.Dst.Bits() == 0 {
+		route.Destination = "default"
+	} else {
+		route.Destination = route.Dst.String()
+	}
+
+	if src, ok := netip.AddrFromSlice(attrs.get(syscall.RTA_SRC)); ok && b[2] > 0 {
+		route.Src = netip.PrefixFrom(src, int(b[2]))
+	}
+
+	if gw, ok := netlinkGateway(attrs); ok {
+		route.Gateway = gw.String()
+	}
+	if mp := attrs.get(syscall.RTA_MULTIPATH); mp != nil {
+		route.NextHops = parseNetlinkMultipath(mp, links)
+		if len(route.NextHops) > 0 {
+			route.Gateway = route.NextHops[0].Gateway
+			route.Netif = route.NextHops[0].Netif
+		}
+	}
+	route.Encap = parseNetlinkEncap(attrs)
+	route.ExpiresAt = netlinkExpiry(attrs, time.Now())
+	if id, ok := attrs.uint32(rtaNHID); ok {
+		route.NextHopID = id
+	}
+	route.Flags = routeFlagsFor(&route).String()
+
+	return route, true
+}
+
+// userHZ is the frequency of clock_t values reported to userspace.
+const userHZ = 100
+
+/* rta_cacheinfo:
++---------+---------+---------+-------+------+----+----+-------+
+| clntref | lastuse | expires | error | used | id | ts | tsage |
++---------+---------+---------+-------+------+----+----+-------+
+    u32       u32       s32      u32    u32   u32  u32   u32
+*/
+
+// netlinkExpiry returns when the route expires, based on the remaining
+// lifetime the kernel reports through RTA_CACHEINFO in clock_t units, or a
+// zero time if it doesn't expire.
+func netlinkExpiry(attrs netlinkAttrs, now time.Time) time.Time {
+	ci := attrs.get(syscall.RTA_CACHEINFO)
+	if len(ci) < 12 {
+		return time.Time{}
+	}
+	expires := int32(binary.NativeEndian.Uint32(ci[8:12]))
+	if expires == 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(expires) * time.Second / userHZ)
+}
+
+// netlinkGateway extracts the next hop address from RTA_GATEWAY, or from
+// RTA_VIA for routes using a next hop from a different family.
+func netlinkGateway(attrs netlinkAttrs) (netip.Addr, bool) {
+	if gw, ok := netip.AddrFromSlice(attrs.get(syscall.RTA_GATEWAY)); ok {
+		return gw, true
+	}
+	// struct rtvia { __kernel_sa_family_t rtvia_family; __u8 rtvia_addr[0]; }
+	if via := attrs.get(rtaVia); len(via) > 2 {
+		return netip.AddrFromSlice(via[2:])
+	}
+	return netip.Addr{}, false
+}
+
+// parseNetlinkMetrics decodes the RTAX_* attributes nested in RTA_METRICS.
+func parseNetlinkMetrics(b []byte) RouteMetrics {
+	const rtaxLock = 1
+	var m RouteMetrics
+	for _, a := range parseNetlinkAttrs(b) {
+		if a.Type == uint16(RouteMetricCCAlgo) {
+			m.CCAlgo = netlinkString(a.Value)
+			continue
+		}
+		if len(a.Value) < 4 {
+			continue
+		}
+		v := binary.NativeEndian.Uint32(a.Value)
+		switch RouteMetric(a.Type) {
+		case rtaxLock:
+			m.Locked = v
+		case RouteMetricMTU:
+			m.MTU = v
+		case RouteMetricWindow:
+			m.Window = v
+		case RouteMetricRTT:
+			// Stored in units of 1/8 ms
+			m.RTT = time.Duration(v) * time.Millisecond / 8
+		case RouteMetricRTTVar:
+			// Stored in units of 1/4 ms
+			m.RTTVar = time.Duration(v) * time.Millisecond / 4
+		case RouteMetricSSThresh:
+			m.SSThresh = v
+		case RouteMetricCWND:
+			m.CWND = v
+		case RouteMetricAdvMSS:
+			m.AdvMSS = v
+		case RouteMetricReordering:
+			m.Reordering = v
+		case RouteMetricHopLimit:
+			m.HopLimit = v
+		case RouteMetricInitCWND:
+			m.InitCWND = v
+		case RouteMetricFeatures:
+			m.Features = v
+		case RouteMetricRTOMin:
+			m.RTOMin = time.Duration(v) * time.Millisecond
+		case RouteMetricInitRWND:
+			m.InitRWND = v
+		case RouteMetricQuickAck:
+			m.QuickAck = v
+		case RouteMetricFastOpenNoCookie:
+			m.FastOpenNoCookie = v
+		}
+	}
+	return m
+}
+
+/* rtnexthop:
++-------+-------+------+---------+-------------+
+|  len  | flags | hops | ifindex | attributes  |
++-------+-------+------+---------+-------------+
+   u16     u8      u8     i32
+*/
+
+// parseNetlinkMultipath parses the list of rtnexthop structures contained in
+// a RTA_MULTIPATH attribute.
+func parseNetlinkMultipath(b []byte, links map[int]string) []NextHop {
+	const sizeofRtNexthop = 8
+	var hops []NextHop
+	for len(b) >= sizeofRtNexthop {
+		l := int(binary.NativeEndian.Uint16(b[0:2]))
+		if l < sizeofRtNexthop || l > len(b) {
+			break
+		}
+		hop := NextHop{
+			Flags:  NextHopFlags(b[2]),
+			Weight: int(b[3]) + 1,
+			Netif:  links[int(int32(binary.NativeEndian.Uint32(b[4:8])))],
+		}
+		attrs := parseNetlinkAttrs(b[sizeofRtNexthop:l])
+		if gw, ok := netlinkGateway(attrs); ok {
+			hop.Gateway = gw.String()
+		}
+		hop.Encap = parseNetlinkEncap(attrs)
+		hops = append(hops, hop)
+
+		if a := netlinkAlign(l); a < len(b) {
+			b = b[a:]
+		} else {
+			break
+		}
+	}
+	return hops
+}
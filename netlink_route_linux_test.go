@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetlinkExpiry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cacheInfo := func(expires int32) netlinkAttrs {
+		ci := make([]byte, 32)
+		binary.NativeEndian.PutUint32(ci[8:12], uint32(expires))
+		return parseNetlinkAttrs(testNetlinkAttr(syscall.RTA_CACHEINFO, ci))
+	}
+
+	assert.Equal(t, now.Add(1799*time.Second+500*time.Millisecond), netlinkExpiry(cacheInfo(179950), now))
+	assert.True(t, netlinkExpiry(cacheInfo(0), now).IsZero())
+	assert.True(t, netlinkExpiry(nil, now).IsZero())
+}
+
+func TestParseNetlinkMultipath(t *testing.T) {
+	// struct rtnexthop { __u16 rtnh_len; __u8 rtnh_flags; __u8 rtnh_hops; int rtnh_ifindex; }
+	rtnexthop := func(flags NextHopFlags, hops uint8, ifindex int32, attrs []byte) []byte {
+		b := binary.NativeEndian.AppendUint16(nil, uint16(8+len(attrs)))
+		b = append(b, byte(flags), hops)
+		b = binary.NativeEndian.AppendUint32(b, uint32(ifindex))
+		return append(b, attrs...)
+	}
+	gw := netip.MustParseAddr("10.0.0.1").As4()
+	via := append(binary.NativeEndian.AppendUint16(nil, syscall.AF_INET6), netip.MustParseAddr("fe80::1").AsSlice()...)
+
+	var b []byte
+	b = append(b, rtnexthop(0, 1, 2, testNetlinkAttr(syscall.RTA_GATEWAY, gw[:]))...)
+	b = append(b, rtnexthop(NextHopOnLink, 0, 3, testNetlinkAttr(rtaVia, via))...)
+	b = append(b, rtnexthop(NextHopDead|NextHopLinkDown, 0, 4, nil)...)
+
+	links := map[int]string{2: "eth0", 3: "eth1", 4: "wg0"}
+	assert.Equal(t, []NextHop{
+		{Gateway: "10.0.0.1", Netif: "eth0", Weight: 2},
+		{Gateway: "fe80::1", Netif: "eth1", Weight: 1, Flags: NextHopOnLink},
+		{Netif: "wg0", Weight: 1, Flags: NextHopDead | NextHopLinkDown},
+	}, parseNetlinkMultipath(b, links))
+
+	t.Run("Truncated", func(t *testing.T) {
+		assert.Len(t, parseNetlinkMultipath(b[:len(b)-4], links), 2)
+		assert.Empty(t, parseNetlinkMultipath(b[:6], links))
+	})
+}
+
+func TestParseNetlinkMetrics(t *testing.T) {
+	u32 := func(v uint32) []byte { return binary.NativeEndian.AppendUint32(nil, v) }
+	var b []byte
+	b = append(b, testNetlinkAttr(1, u32(1<<RouteMetricMTU))...)
+	b = append(b, testNetlinkAttr(uint16(RouteMetricMTU), u32(1400))...)
+	b = append(b, testNetlinkAttr(uint16(RouteMetricRTT), u32(240))...)
+	b = append(b, testNetlinkAttr(uint16(RouteMetricRTTVar), u32(40))...)
+	b = append(b, testNetlinkAttr(uint16(RouteMetricInitCWND), u32(10))...)
+	b = append(b, testNetlinkAttr(uint16(RouteMetricRTOMin), u32(200))...)
+	b = append(b, testNetlinkAttr(uint16(RouteMetricCCAlgo), []byte("bbr\x00"))...)
+	// Truncated values are ignored.
+	b = append(b, testNetlinkAttr(uint16(RouteMetricHopLimit), []byte{64})...)
+
+	m := parseNetlinkMetrics(b)
+	assert.Equal(t, RouteMetrics{
+		MTU:      1400,
+		RTT:      30 * time.Millisecond,
+		RTTVar:   10 * time.Millisecond,
+		InitCWND: 10,
+		RTOMin:   200 * time.Millisecond,
+		CCAlgo:   "bbr",
+		Locked:   1 << RouteMetricMTU,
+	}, m)
+	assert.True(t, m.IsLocked(RouteMetricMTU))
+	assert.False(t, m.IsLocked(RouteMetricInitCWND))
+}
+
+func TestNetlinkMetrics(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"route", "replace", "default", "via", "10.9.0.1", "dev", "lo", "onlink",
+			"initcwnd", "10", "mtu", "lock", "1400", "advmss", "1360"},
+	})
+
+	routes, err := ns.FindDefaultRoutes()
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	m := routes[0].Metrics
+	assert.Equal(t, RouteMetrics{MTU: 1400, AdvMSS: 1360, InitCWND: 10, Locked: 1 << RouteMetricMTU}, m)
+	assert.True(t, m.IsLocked(RouteMetricMTU))
+}
@@ -1,7 +1,9 @@
 package gateway
 
 import (
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -10,6 +12,7 @@ const (
 	nsNetif       = "Netif"
 	nsGateway     = "Gateway"
 	nsInterface   = "Interface"
+	nsExpire      = "Expire"
 )
 
 type netstatParserState int
@@ -28,6 +31,8 @@ type netstatParser struct {
 	netData    NetRouteList
 	net4Fields map[string]int
 	net6Fields map[string]int
+	// now returns the time Expire values are relative to.
+	now func() time.Time
 }
 
 func (n *netstatParser) feed(line string) error {
@@ -116,6 +121,9 @@ func (n *netstatParser) parseInternetHeader4(line string) {
 		// Other BSD (Solaris, Darwin...)
 		n.net4Fields[nsNetif] = netif
 	}
+	if expire := fields.fieldIdx(nsExpire); expire != -1 {
+		n.net4Fields[nsExpire] = expire
+	}
 
 	n.state = netstatParserStateInternet4Data
 }
@@ -129,10 +137,12 @@ func (n *netstatParser) parseInternet4Data(line string) {
 	fields := strings.Fields(line)
 	n.netData = append(n.netData, NetRoute{
 		Kind:        NetRouteKindV4,
+		Type:        netstatRouteType(fields[n.net4Fields[nsFlags]]),
 		Destination: fields[n.net4Fields[nsDestination]],
 		Flags:       fields[n.net4Fields[nsFlags]],
 		Netif:       fields[n.net4Fields[nsNetif]],
 		Gateway:     fields[n.net4Fields[nsGateway]],
+		ExpiresAt:   n.expiresAt(fields, n.net4Fields),
 	})
 }
 
@@ -166,6 +176,9 @@ func (n *netstatParser) parseInternetHeader6(line string) {
 		// Other BSD (Solaris, Darwin...)
 		n.net6Fields[nsNetif] = netif
 	}
+	if expire := fields.fieldIdx(nsExpire); expire != -1 {
+		n.net6Fields[nsExpire] = expire
+	}
 
 	n.state = netstatParserStateInternet6Data
 }
@@ -179,13 +192,50 @@ func (n *netstatParser) parseInternet6Data(line string) {
 	fields := strings.Fields(line)
 	n.netData = append(n.netData, NetRoute{
 		Kind:        NetRouteKindV6,
+		Type:        netstatRouteType(fields[n.net6Fields[nsFlags]]),
 		Destination: fields[n.net6Fields[nsDestination]],
 		Flags:       fields[n.net6Fields[nsFlags]],
 		Netif:       fields[n.net6Fields[nsNetif]],
 		Gateway:     fields[n.net6Fields[nsGateway]],
+		ExpiresAt:   n.expiresAt(fields, n.net6Fields),
 	})
 }
 
+// netstatRouteType decodes the route type from BSD netstat flag letters:
+// B (RTF_BLACKHOLE), R (RTF_REJECT), b (RTF_BROADCAST) and m (RTF_MULTICAST).
+func netstatRouteType(flags string) RouteType {
+	switch {
+	case strings.Contains(flags, "B"):
+		return RouteTypeBlackhole
+	case strings.Contains(flags, "R"):
+		return RouteTypeUnreachable
+	case strings.Contains(flags, "b"):
+		return RouteTypeBroadcast
+	case strings.Contains(flags, "m"):
+		return RouteTypeMulticast
+	default:
+		return RouteTypeUnicast
+	}
+}
+
+// expiresAt decodes the optional Expire column, holding the seconds left
+// until the route expires, or "!" for routes already expired. Since it is
+// the last column, it is blank for routes that don't expire.
+func (n *netstatParser) expiresAt(fields []string, idx map[string]int) time.Time {
+	i, ok := idx[nsExpire]
+	if !ok || i >= len(fields) {
+		return time.Time{}
+	}
+	if fields[i] == "!" {
+		return n.now()
+	}
+	secs, err := strconv.Atoi(fields[i])
+	if err != nil {
+		return time.Time{}
+	}
+	return n.now().Add(time.Duration(secs) * time.Second)
+}
+
 func (n *netstatParser) result() NetRouteList {
 	newList := make(NetRouteList, len(n.netData))
 	for i, v := range n.netData {
@@ -200,5 +250,6 @@ func newNetstatParser() *netstatParser {
 		netData:    nil,
 		net4Fields: map[string]int{},
 		net6Fields: map[string]int{},
+		now:        time.Now,
 	}
 }
@@ -0,0 +1,78 @@
+package gateway
+
+// NextHopObject represents a Linux nexthop object, as listed by
+// `ip nexthop`. Objects either describe a single next hop, or a group of
+// other objects.
+type NextHopObject struct {
+	ID uint32
+	// Kind is the family of the next hop. It is zero for groups.
+	Kind      NetRouteKind
+	Gateway   string
+	Netif     string
+	Blackhole bool
+	Flags     NextHopFlags
+	Protocol  RouteProtocol
+	// Encap describes the encapsulation applied by the next hop, if any.
+	Encap *RouteEncap
+	// Group lists members of a nexthop group. It is empty for single next
+	// hops.
+	Group []NextHopGroupMember
+}
+
+// NextHopGroupMember represents an entry of a nexthop group.
+type NextHopGroupMember struct {
+	ID     uint32
+	Weight int
+}
+
+// resolveNextHops replaces next hop information of routes referring to
+// nexthop objects (`ip route ... nhid N`) with the concrete gateways and
+// interfaces of the referred object. Blackhole members of groups are left
+// out, as the kernel never selects them while other paths are available.
+func (n NetRouteList) resolveNextHops(objects []NextHopObject) {
+	byID := make(map[uint32]*NextHopObject, len(objects))
+	for i := range objects {
+		byID[objects[i].ID] = &objects[i]
+	}
+
+	for i := range n {
+		r := &n[i]
+		obj, ok := byID[r.NextHopID]
+		if r.NextHopID == 0 || !ok {
+			continue
+		}
+
+		if obj.Blackhole {
+			r.Type = RouteTypeBlackhole
+		} else if len(obj.Group) == 0 {
+			r.Gateway, r.Netif, r.NextHops = obj.Gateway, obj.Netif, nil
+			r.HopFlags, r.Encap = obj.Flags, obj.Encap
+		} else {
+			r.NextHops = r.NextHops[:0]
+			blackhole := false
+			for _, m := range obj.Group {
+				member, ok := byID[m.ID]
+				if !ok {
+					continue
+				}
+				if member.Blackhole {
+					blackhole = true
+					continue
+				}
+				r.NextHops = append(r.NextHops, NextHop{
+					Gateway: member.Gateway,
+					Netif:   member.Netif,
+					Weight:  m.Weight,
+					Flags:   member.Flags,
+					Encap:   member.Encap,
+				})
+			}
+			if len(r.NextHops) > 0 {
+				r.Gateway, r.Netif = r.NextHops[0].Gateway, r.NextHops[0].Netif
+			} else if blackhole {
+				r.Type = RouteTypeBlackhole
+			}
+		}
+		r.Flags = routeFlagsFor(r).String()
+	}
+}
@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"syscall"
+)
+
+/* Keep this in sync with /usr/src/linux/include/uapi/linux/nexthop.h */
+
+const (
+	rtmNewNextHop = 104
+	rtmGetNextHop = 106
+
+	nhaID        = 1
+	nhaGroup     = 2
+	nhaBlackhole = 4
+	nhaOIf       = 5
+	nhaGateway   = 6
+	nhaEncapType = 7
+	nhaEncap     = 8
+
+	sizeofNhMsg      = 8
+	sizeofNextHopGrp = 8
+)
+
+// ListNextHops returns all nexthop objects and groups, as listed by
+// `ip nexthop`.
+func ListNextHops() ([]NextHopObject, error) {
+	links, err := linkNames()
+	if err != nil {
+		return nil, err
+	}
+	return netlinkNextHops(links)
+}
+
+func netlinkNextHops(links map[int]string) ([]NextHopObject, error) {
+	msgs, err := netlinkDump(rtmGetNextHop, make([]byte, sizeofNhMsg))
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []NextHopObject
+	for _, m := range msgs {
+		if m.Header.Type != rtmNewNextHop {
+			continue
+		}
+		if obj, ok := parseNetlinkNextHop(m.Data, links); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+/* nhmsg:
++--------+-------+----------+-------+-------+
+| family | scope | protocol | resvd | flags |
++--------+-------+----------+-------+-------+
+    u8      u8        u8       u8     u32
+*/
+
+func parseNetlinkNextHop(b []byte, links map[int]string) (NextHopObject, bool) {
+	if len(b) < sizeofNhMsg {
+		return NextHopObject{}, false
+	}
+	attrs := parseNetlinkAttrs(b[sizeofNhMsg:])
+	id, ok := attrs.uint32(nhaID)
+	if !ok {
+		return NextHopObject{}, false
+	}
+
+	obj := NextHopObject{
+		ID:        id,
+		Protocol:  RouteProtocol(b[2]),
+		Flags:     NextHopFlags(binary.NativeEndian.Uint32(b[4:8])),
+		Blackhole: attrs.get(nhaBlackhole) != nil,
+	}
+	switch b[0] {
+	case syscall.AF_INET:
+		obj.Kind = NetRouteKindV4
+	case syscall.AF_INET6:
+		obj.Kind = NetRouteKindV6
+	}
+	if gw, ok := netip.AddrFromSlice(attrs.get(nhaGateway)); ok {
+		obj.Gateway = gw.String()
+	}
+	if oif, ok := attrs.uint32(nhaOIf); ok {
+		obj.Netif = links[int(oif)]
+	}
+	obj.Encap = decodeNetlinkEncap(attrs.get(nhaEncapType), attrs.get(nhaEncap))
+
+	// struct nexthop_grp { __u32 id; __u8 weight; __u8 weight_high; __u16 resvd2; }
+	group := attrs.get(nhaGroup)
+	for len(group) >= sizeofNextHopGrp {
+		weight := int(group[5])<<8 | int(group[4])
+		obj.Group = append(obj.Group, NextHopGroupMember{
+			ID:     binary.NativeEndian.Uint32(group[0:4]),
+			Weight: weight + 1,
+		})
+		group = group[sizeofNextHopGrp:]
+	}
+
+	return obj, true
+}
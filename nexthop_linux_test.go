@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetlinkNextHop(t *testing.T) {
+	nhmsg := func(family uint8, protocol RouteProtocol, flags NextHopFlags, attrs ...[]byte) []byte {
+		b := []byte{family, 0, byte(protocol), 0}
+		b = binary.NativeEndian.AppendUint32(b, uint32(flags))
+		for _, a := range attrs {
+			b = append(b, a...)
+		}
+		return b
+	}
+	u32 := func(v uint32) []byte { return binary.NativeEndian.AppendUint32(nil, v) }
+	links := map[int]string{2: "eth0"}
+
+	t.Run("Single", func(t *testing.T) {
+		gw := netip.MustParseAddr("10.0.0.1").As4()
+		dst := netip.MustParseAddr("192.0.2.9").As4()
+		encap := append(testNetlinkAttr(lwtunnelIPID, binary.BigEndian.AppendUint64(nil, 42)),
+			testNetlinkAttr(lwtunnelIPDst, dst[:])...)
+
+		obj, ok := parseNetlinkNextHop(nhmsg(syscall.AF_INET, RouteProtocolStatic, NextHopOnLink,
+			testNetlinkAttr(nhaID, u32(1)),
+			testNetlinkAttr(nhaOIf, u32(2)),
+			testNetlinkAttr(nhaGateway, gw[:]),
+			testNetlinkAttr(nhaEncapType, binary.NativeEndian.AppendUint16(nil, uint16(EncapTypeIP))),
+			testNetlinkAttr(nhaEncap, encap),
+		), links)
+		require.True(t, ok)
+		assert.Equal(t, NextHopObject{
+			ID:       1,
+			Kind:     NetRouteKindV4,
+			Gateway:  "10.0.0.1",
+			Netif:    "eth0",
+			Flags:    NextHopOnLink,
+			Protocol: RouteProtocolStatic,
+			Encap:    &RouteEncap{Type: EncapTypeIP, TunnelID: 42, TunnelDst: netip.MustParseAddr("192.0.2.9")},
+		}, obj)
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		// struct nexthop_grp { __u32 id; __u8 weight; __u8 weight_high; __u16 resvd2; }
+		group := append(u32(1), 2, 0, 0, 0)
+		group = append(append(group, u32(2)...), 0xff, 0x01, 0, 0)
+
+		obj, ok := parseNetlinkNextHop(nhmsg(syscall.AF_UNSPEC, RouteProtocolUnspec, 0,
+			testNetlinkAttr(nhaID, u32(10)),
+			testNetlinkAttr(nhaGroup, group),
+		), links)
+		require.True(t, ok)
+		assert.Equal(t, NextHopObject{
+			ID:    10,
+			Group: []NextHopGroupMember{{ID: 1, Weight: 3}, {ID: 2, Weight: 512}},
+		}, obj)
+	})
+
+	t.Run("Blackhole", func(t *testing.T) {
+		obj, ok := parseNetlinkNextHop(nhmsg(syscall.AF_INET, RouteProtocolUnspec, 0,
+			testNetlinkAttr(nhaID, u32(20)),
+			testNetlinkAttr(nhaBlackhole, nil),
+		), links)
+		require.True(t, ok)
+		assert.True(t, obj.Blackhole)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, ok := parseNetlinkNextHop(nhmsg(syscall.AF_INET, RouteProtocolUnspec, 0), links)
+		assert.False(t, ok)
+		_, ok = parseNetlinkNextHop([]byte{syscall.AF_INET}, links)
+		assert.False(t, ok)
+	})
+}
+
+func TestListNextHops(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"nexthop", "add", "id", "1", "via", "10.9.0.2", "dev", "lo", "onlink"},
+		{"nexthop", "add", "id", "2", "encap", "ip", "id", "42", "dst", "192.0.2.9", "via", "10.9.0.3", "dev", "lo", "onlink"},
+		{"nexthop", "add", "id", "3", "blackhole"},
+		{"nexthop", "add", "id", "10", "group", "1/2,3"},
+		{"nexthop", "add", "id", "11", "group", "3"},
+		{"route", "replace", "default", "nhid", "10"},
+		{"route", "add", "10.50.0.0/16", "nhid", "2"},
+		{"route", "add", "10.60.0.0/16", "nhid", "11"},
+	})
+
+	var objects []NextHopObject
+	var routes NetRouteList
+	require.NoError(t, ns.Do(func() (err error) {
+		if objects, err = ListNextHops(); err != nil {
+			return err
+		}
+		routes, err = netlinkRoutes()
+		return err
+	}))
+
+	require.Len(t, objects, 5)
+	byID := map[uint32]NextHopObject{}
+	for _, o := range objects {
+		byID[o.ID] = o
+	}
+	assert.Equal(t, "10.9.0.2", byID[1].Gateway)
+	assert.Equal(t, "lo", byID[1].Netif)
+	assert.Equal(t, NextHopOnLink, byID[1].Flags)
+	require.NotNil(t, byID[2].Encap)
+	assert.Equal(t, EncapTypeIP, byID[2].Encap.Type)
+	assert.Equal(t, uint64(42), byID[2].Encap.TunnelID)
+	assert.True(t, byID[3].Blackhole)
+	assert.Equal(t, []NextHopGroupMember{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}}, byID[10].Group)
+
+	byDst := map[netip.Prefix]NetRoute{}
+	for _, r := range routes {
+		if r.Table == routeTableMain {
+			byDst[r.Dst] = r
+		}
+	}
+	def := byDst[netip.MustParsePrefix("0.0.0.0/0")]
+	require.Len(t, def.NextHops, 2)
+	assert.Equal(t, "10.9.0.2", def.NextHops[0].Gateway)
+	assert.Equal(t, "10.9.0.3", def.NextHops[1].Gateway)
+	require.NotNil(t, def.NextHops[1].Encap)
+	assert.Equal(t, uint64(42), def.NextHops[1].Encap.TunnelID)
+
+	single := byDst[netip.MustParsePrefix("10.50.0.0/16")]
+	assert.Equal(t, "10.9.0.3", single.Gateway)
+	assert.Equal(t, NextHopOnLink, single.HopFlags)
+	require.NotNil(t, single.Encap)
+	assert.Equal(t, netip.MustParseAddr("192.0.2.9"), single.Encap.TunnelDst)
+
+	assert.Equal(t, RouteTypeBlackhole, byDst[netip.MustParsePrefix("10.60.0.0/16")].Type)
+}
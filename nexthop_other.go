@@ -0,0 +1,9 @@
+//go:build !linux
+
+package gateway
+
+// ListNextHops returns all nexthop objects and groups. Nexthop objects are
+// only supported on Linux.
+func ListNextHops() ([]NextHopObject, error) {
+	return nil, &ErrNotImplemented{}
+}
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveNextHops(t *testing.T) {
+	objects := []NextHopObject{
+		{ID: 1, Kind: NetRouteKindV4, Gateway: "10.0.0.1", Netif: "eth0"},
+		{ID: 2, Kind: NetRouteKindV4, Gateway: "10.1.0.1", Netif: "eth1", Flags: NextHopLinkDown},
+		{ID: 3, Kind: NetRouteKindV4, Gateway: "10.2.0.1", Netif: "eth2", Flags: NextHopOnLink, Encap: &RouteEncap{Type: EncapTypeMPLS, Labels: []uint32{100}}},
+		{ID: 10, Group: []NextHopGroupMember{{ID: 1, Weight: 3}, {ID: 2, Weight: 1}, {ID: 20, Weight: 1}}},
+		{ID: 11, Group: []NextHopGroupMember{{ID: 20, Weight: 1}}},
+		{ID: 20, Blackhole: true},
+	}
+	routes := NetRouteList{
+		{Kind: NetRouteKindV4, Destination: "default", Dst: netip.MustParsePrefix("0.0.0.0/0"), Flags: "U", NextHopID: 10},
+		{Kind: NetRouteKindV4, Destination: "10.9.0.0/16", Dst: netip.MustParsePrefix("10.9.0.0/16"), Flags: "U", NextHopID: 1},
+		{Kind: NetRouteKindV4, Destination: "10.8.0.0/16", Dst: netip.MustParsePrefix("10.8.0.0/16"), Flags: "U", NextHopID: 20},
+		{Kind: NetRouteKindV4, Destination: "10.7.0.0/16", Dst: netip.MustParsePrefix("10.7.0.0/16"), Flags: "U", Netif: "eth0"},
+		{Kind: NetRouteKindV4, Destination: "10.6.0.0/16", Dst: netip.MustParsePrefix("10.6.0.0/16"), Flags: "U", NextHopID: 3},
+		{Kind: NetRouteKindV4, Destination: "10.5.0.0/16", Dst: netip.MustParsePrefix("10.5.0.0/16"), Flags: "U", NextHopID: 11},
+	}
+	routes.resolveNextHops(objects)
+
+	assert.Equal(t, "UG", routes[0].Flags)
+	assert.Equal(t, "10.0.0.1", routes[0].Gateway)
+	assert.Equal(t, []NextHop{
+		{Gateway: "10.0.0.1", Netif: "eth0", Weight: 3},
+		{Gateway: "10.1.0.1", Netif: "eth1", Weight: 1, Flags: NextHopLinkDown},
+	}, routes[0].NextHops)
+
+	assert.Equal(t, "UG", routes[1].Flags)
+	assert.Equal(t, "eth0", routes[1].Netif)
+	assert.Empty(t, routes[1].NextHops)
+
+	assert.Equal(t, RouteTypeBlackhole, routes[2].Type)
+	assert.Equal(t, "U!", routes[2].Flags)
+
+	assert.Equal(t, "U", routes[3].Flags)
+
+	assert.Equal(t, NextHopOnLink, routes[4].HopFlags)
+	assert.True(t, routes[4].IsEncapsulated())
+
+	assert.Equal(t, RouteTypeBlackhole, routes[5].Type)
+
+	setRoutes(t, routes)
+	gateways, err := FindDefaultGateways()
+	require.NoError(t, err)
+	assert.Len(t, gateways, 2)
+}
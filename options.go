@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"net/netip"
+	"slices"
+)
+
+// DefaultsOption customizes which routes are considered by FindDefaults and
+// the FindDefault* family of functions.
+type DefaultsOption func(*defaultsOptions)
+
+type defaultsOptions struct {
+	table            uint32
+	nonForwarding    bool
+	protocols        []RouteProtocol
+	excludeProtocols []RouteProtocol
+	source           netip.Addr
+	excludeAddrFlags AddrFlags
+	excludeLinkLocal bool
+}
+
+func newDefaultsOptions(opts []DefaultsOption) *defaultsOptions {
+	o := &defaultsOptions{table: routeTableMain}
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
+// InTable makes discovery consider routes of the provided routing table
+// instead of the main one. Routes from backends unable to report their table
+// are assumed to belong to the main table.
+func InTable(table uint32) DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.table = table
+	}
+}
+
+// IncludeNonForwarding makes FindDefaults also report default routes which
+// don't forward traffic, such as blackhole, unreachable or prohibit defaults.
+// This is mostly useful for diagnostics.
+func IncludeNonForwarding() DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.nonForwarding = true
+	}
+}
+
+// WithProtocols restricts discovery to routes installed by one of the
+// provided protocols. Routes from backends unable to report their origin
+// have RouteProtocolUnspec, and are left out unless it is listed.
+func WithProtocols(protocols ...RouteProtocol) DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.protocols = append(o.protocols, protocols...)
+	}
+}
+
+// ExcludeProtocols ignores routes installed by any of the provided
+// protocols, such as defaults installed by a VPN daemon.
+func ExcludeProtocols(protocols ...RouteProtocol) DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.excludeProtocols = append(o.excludeProtocols, protocols...)
+	}
+}
+
+// FromSource restricts discovery to default routes used by traffic from the
+// provided source address, which must be of the same family. Source-specific
+// routes (see NetRoute.Src) are preferred over those matching any source, as
+// done by the kernel, so that the gateway used by each upstream of a
+// multihomed network can be told apart.
+func FromSource(src netip.Addr) DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.source = src
+	}
+}
+
+// ExcludeAddrFlags makes FindDefaultIPs and FindDefaultAddrs leave out
+// addresses having any of the provided flags, such as tentative addresses
+// still undergoing duplicate address detection, which can't be used yet.
+// Flags are only reported on Linux.
+func ExcludeAddrFlags(flags AddrFlags) DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.excludeAddrFlags |= flags
+	}
+}
+
+// ExcludeLinkLocal makes FindDefaultIPs and FindDefaultAddrs leave out
+// link-local addresses, which are meaningless to peers of other links.
+func ExcludeLinkLocal() DefaultsOption {
+	return func(o *defaultsOptions) {
+		o.excludeLinkLocal = true
+	}
+}
+
+func (o *defaultsOptions) acceptsAddr(a InterfaceAddr) bool {
+	if o.excludeLinkLocal && a.Prefix.Addr().IsLinkLocalUnicast() {
+		return false
+	}
+	return a.Flags&o.excludeAddrFlags == 0
+}
+
+func (o *defaultsOptions) acceptsProtocol(p RouteProtocol) bool {
+	if len(o.protocols) > 0 && !slices.Contains(o.protocols, p) {
+		return false
+	}
+	return !slices.Contains(o.excludeProtocols, p)
+}
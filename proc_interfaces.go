@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"net/netip"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+/* dev:
+Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 19853215    2431    0    0    0     0          0         0 19853215    2431    0    0    0     0       0          0
+  eth0:    1188      17    0    0    0     0          0         0     1489      17    0    0    0     0       0          0
+*/
+
+// parseProcNetDev returns the names of interfaces listed by /proc/net/dev.
+func parseProcNetDev(data string) []string {
+	var names []string
+	for _, v := range strings.Split(data, "\n") {
+		name, _, ok := strings.Cut(v, ":")
+		if !ok || strings.Contains(name, "|") {
+			continue
+		}
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+/* if_inet6:
+fe8000000000000000fc00fffe000001 04 40 20 80     eth0
++------------------------------+ ++ ++ ++ ++     ++
+|                                |  |  |  |      |
+1                                2  3  4  5      6
+
+  1. IPv6 address displayed in 32 hexadecimal chars without colons as separator
+  2. Interface index in hexadecimal
+  3. Prefix length in hexadecimal
+  4. Scope in hexadecimal
+  5. Flags in hexadecimal
+  6. Device name
+*/
+
+// parseProcIfInet6 returns the IPv6 addresses listed by /proc/net/if_inet6,
+// along with their flags, indexed by interface name.
+func parseProcIfInet6(data string) (map[string][]InterfaceAddr, error) {
+	addrs := map[string][]InterfaceAddr{}
+	for _, v := range strings.Split(data, "\n") {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		fields := strings.Fields(v)
+		if len(fields) != 6 {
+			return nil, &ErrInvalidRouteFileFormat{row: v}
+		}
+		addr, ok := ip6FromHex(fields[0])
+		if !ok {
+			return nil, &ErrInvalidRouteFileFormat{row: v}
+		}
+		bits, err := strconv.ParseUint(fields[2], 16, 8)
+		if err != nil || bits > 128 {
+			return nil, &ErrInvalidRouteFileFormat{row: v}
+		}
+		// Flags are truncated to 8 bits, as the field predates IFA_FLAGS.
+		flags, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			return nil, &ErrInvalidRouteFileFormat{row: v}
+		}
+		addrs[fields[5]] = append(addrs[fields[5]], InterfaceAddr{
+			Netif:  fields[5],
+			Prefix: netip.PrefixFrom(addr, int(bits)),
+			Flags:  AddrFlags(flags),
+		})
+	}
+	return addrs, nil
+}
+
+// procAddrsIPv4 returns the IPv4 addresses found in dir, laid out like
+// /proc/net, indexed by interface name. procfs doesn't list them directly:
+// local addresses are taken from fib_trie, and assigned to the interface of
+// the most specific directly connected route covering them, whose prefix
+// length is used.
+func procAddrsIPv4(readFile func(string) ([]byte, error), dir string) (map[string][]netip.Prefix, error) {
+	trie, err := getRoutesFibTrie(readFile, path.Join(dir, "fib_trie"))
+	if os.IsNotExist(err) {
+		// Without fib_trie, no IPv4 address can be found.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	routes, err := getRoutesIPv4(readFile, path.Join(dir, "route"))
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := map[string][]netip.Prefix{}
+	for _, t := range trie {
+		if t.Table != routeTableLocal || t.Type != RouteTypeLocal || !t.Dst.IsSingleIP() {
+			continue
+		}
+		addr := t.Dst.Addr()
+		if addr.IsLoopback() {
+			// The loopback network is only routed through the local table.
+			addrs["lo"] = append(addrs["lo"], netip.PrefixFrom(addr, 8))
+			continue
+		}
+		var best *NetRoute
+		for i := range routes {
+			r := &routes[i]
+			if r.Type != RouteTypeUnicast || r.HasFlags("G") || !r.Dst.IsValid() || r.Dst.Bits() == 0 {
+				continue
+			}
+			if r.Dst.Contains(addr) && (best == nil || r.Dst.Bits() > best.Dst.Bits()) {
+				best = r
+			}
+		}
+		if best != nil {
+			addrs[best.Netif] = append(addrs[best.Netif], netip.PrefixFrom(addr, best.Dst.Bits()))
+		}
+	}
+	return addrs, nil
+}
+
+// procInterfaceAddrs returns the addresses of the named interface, as found in
+// dir, laid out like /proc/net, using readFile. See procAddrsIPv4 for how IPv4
+// addresses are found.
+func procInterfaceAddrs(readFile func(string) ([]byte, error), dir, name string) ([]InterfaceAddr, error) {
+	dev, err := readFile(path.Join(dir, "dev"))
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, v := range parseProcNetDev(string(dev)) {
+		found = found || v == name
+	}
+	if !found {
+		return nil, &ErrInterfaceNotFound{name: name}
+	}
+
+	v4, err := procAddrsIPv4(readFile, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	inet6, err := readFile(path.Join(dir, "if_inet6"))
+	if os.IsNotExist(err) {
+		// IPv6 is disabled.
+		return withAddrFlags(name, v4[name], nil), nil
+	} else if err != nil {
+		return nil, err
+	}
+	v6, err := parseProcIfInet6(string(inet6))
+	if err != nil {
+		return nil, err
+	}
+	return append(withAddrFlags(name, v4[name], nil), v6[name]...), nil
+}
@@ -3,16 +3,52 @@ package gateway
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"net"
 	"net/netip"
 	"os"
+	"path"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
-var (
-	routeV4 = "/proc/net/route"
-	routeV6 = "/proc/net/ipv6_route"
-)
+// procNetDir lists routes of the namespace of the current process.
+const procNetDir = "/proc/net"
+
+// readProcRoutes reads routes from procNet, a directory laid out like
+// /proc/net, using readFile. Since route only lists the main table, IPv4
+// routes of other tables are taken from fib_trie, when available. A missing
+// route file is an error, except for ipv6_route when IPv6 is disabled, as
+// shown by the absence of if_inet6.
+func readProcRoutes(readFile func(string) ([]byte, error), procNet string) (NetRouteList, error) {
+	ip6List, err := getRoutesIPv6(readFile, path.Join(procNet, "ipv6_route"))
+	if os.IsNotExist(err) {
+		if _, inet6Err := readFile(path.Join(procNet, "if_inet6")); os.IsNotExist(inet6Err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ip4List, err := getRoutesIPv4(readFile, path.Join(procNet, "route"))
+	if err != nil {
+		return nil, err
+	}
+
+	trie, err := getRoutesFibTrie(readFile, path.Join(procNet, "fib_trie"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, r := range trie {
+		if r.Table != routeTableMain {
+			ip4List = append(ip4List, r)
+		}
+	}
+
+	return append(ip4List, ip6List...), nil
+}
 
 /* ipv6_route:
 00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000001 00200200 lo
@@ -40,6 +76,7 @@ func ip6FromHex(in string) (ip netip.Addr, ok bool) {
 	v, err := hex.DecodeString(in)
 	if err != nil {
 		ok = false
+		return
 	}
 	ip = netip.AddrFrom16([16]byte(v))
 	ok = true
@@ -51,10 +88,29 @@ func parseSingleRouteIPv6(fields []string) *NetRoute {
 	if !ok {
 		return nil
 	}
+	dst, ok := procPrefixIPv6(dstNet, fields[1])
+	if !ok {
+		return nil
+	}
+	srcNet, ok := ip6FromHex(fields[2])
+	if !ok {
+		return nil
+	}
+	src, ok := procPrefixIPv6(srcNet, fields[3])
+	if !ok {
+		return nil
+	}
+	if src.Bits() == 0 {
+		src = netip.Prefix{}
+	}
 	nextHop, ok := ip6FromHex(fields[4])
 	if !ok {
 		return nil
 	}
+	metric, err := strconv.ParseUint(fields[5], 16, 32)
+	if err != nil {
+		return nil
+	}
 	rawFlags, err := hex.DecodeString(fields[8])
 	if err != nil {
 		return nil
@@ -64,19 +120,44 @@ func parseSingleRouteIPv6(fields []string) *NetRoute {
 	ifName := fields[9]
 	return &NetRoute{
 		Kind:        NetRouteKindV6,
-		Destination: dstNet.String(),
+		Type:        procRouteTypeIPv6(flags),
+		Destination: dst.String(),
 		Flags:       flags.String(),
 		Netif:       ifName,
 		Gateway:     nextHop.String(),
+		Metric:      uint32(metric),
+		Dst:         dst,
+		Src:         src,
+	}
+}
+
+// procPrefixIPv6 returns the prefix of addr having the length represented by
+// the hexadecimal bits.
+func procPrefixIPv6(addr netip.Addr, bits string) (netip.Prefix, bool) {
+	n, err := strconv.ParseUint(bits, 16, 8)
+	if err != nil || n > 128 {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(addr, int(n)), true
+}
+
+// procRouteTypeIPv6 infers the route type from ipv6_route flags. The kernel
+// flags all blackhole, unreachable, prohibit and throw routes as rejecting,
+// so those are all reported as unreachable.
+func procRouteTypeIPv6(flags routeTableFlag) RouteType {
+	switch {
+	case flags.Is(rtfReject):
+		return RouteTypeUnreachable
+	case flags.Is(rtfLocal):
+		return RouteTypeLocal
+	default:
+		return RouteTypeUnicast
 	}
 }
 
-func getRoutesIPv6(source string) (NetRouteList, error) {
-	f, err := os.ReadFile(source)
+func getRoutesIPv6(readFile func(string) ([]byte, error), source string) (NetRouteList, error) {
+	f, err := readFile(source)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
@@ -119,6 +200,7 @@ func ip4FromHex(in string) (ip netip.Addr, ok bool) {
 	v, err := hex.DecodeString(in)
 	if err != nil {
 		ok = false
+		return
 	}
 	slices.Reverse(v)
 	ip = netip.AddrFrom4([4]byte(v))
@@ -126,12 +208,25 @@ func ip4FromHex(in string) (ip netip.Addr, ok bool) {
 	return
 }
 
-func getRoutesIPv4(source string) (NetRouteList, error) {
-	f, err := os.ReadFile(source)
+// procRouteTypeIPv4 infers the route type from /proc/net/route flags and
+// interface. Unreachable and prohibit routes are both flagged as rejecting,
+// and are reported as unreachable. Blackhole and throw routes have no flags
+// of their own, and are listed the same way, without an interface; their
+// type is left unspecified.
+func procRouteTypeIPv4(flags routeTableFlag, iface string) RouteType {
+	switch {
+	case flags.Is(rtfReject):
+		return RouteTypeUnreachable
+	case iface == "*":
+		return RouteTypeUnspec
+	default:
+		return RouteTypeUnicast
+	}
+}
+
+func getRoutesIPv4(readFile func(string) ([]byte, error), source string) (NetRouteList, error) {
+	f, err := readFile(source)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
@@ -145,6 +240,11 @@ func getRoutesIPv4(source string) (NetRouteList, error) {
 	dstNetIdx := fields.fieldIdx("Destination")
 	gatewayIdx := fields.fieldIdx("Gateway")
 	flagsIdx := fields.fieldIdx("Flags")
+	metricIdx := fields.fieldIdx("Metric")
+	mtuIdx := fields.fieldIdx("MTU")
+	windowIdx := fields.fieldIdx("Window")
+	irttIdx := fields.fieldIdx("IRTT")
+	maskIdx := fields.fieldIdx("Mask")
 
 	if ifNameIdx == -1 || dstNetIdx == -1 || gatewayIdx == -1 || flagsIdx == -1 {
 		return nil, &ErrCantParse{}
@@ -174,14 +274,66 @@ func getRoutesIPv4(source string) (NetRouteList, error) {
 		}
 		flags := routeTableFlag(binary.BigEndian.Uint16(rawFlags))
 
+		var metrics RouteMetrics
+		// The kernel reports advmss+40 in the MTU column.
+		if v := procDecimalField(fields, mtuIdx); v > 40 {
+			metrics.AdvMSS = v - 40
+		}
+		metrics.Window = procDecimalField(fields, windowIdx)
+		metrics.RTT = time.Duration(procDecimalField(fields, irttIdx)) * time.Millisecond
+
 		routes = append(routes, NetRoute{
 			Kind:        NetRouteKindV4,
+			Type:        procRouteTypeIPv4(flags, fields[ifNameIdx]),
 			Destination: dstNet.String(),
 			Flags:       flags.String(),
 			Netif:       fields[ifNameIdx],
 			Gateway:     gateway.String(),
+			Metric:      procDecimalField(fields, metricIdx),
+			Metrics:     metrics,
+			Dst:         procPrefixIPv4(dstNet, fields, maskIdx),
 		})
 	}
 
 	return routes, nil
 }
+
+// procPrefixIPv4 returns the destination prefix of a /proc/net/route entry,
+// or an invalid prefix in case its mask is absent or not contiguous.
+func procPrefixIPv4(dst netip.Addr, fields []string, maskIdx int) netip.Prefix {
+	if maskIdx < 0 || maskIdx >= len(fields) {
+		return netip.Prefix{}
+	}
+	mask, ok := ip4FromHex(fields[maskIdx])
+	if !ok {
+		return netip.Prefix{}
+	}
+	return prefixFromMask(dst, mask)
+}
+
+// prefixFromMask returns the prefix of dst having the given IPv4 netmask, or
+// an invalid prefix in case the mask is not contiguous.
+func prefixFromMask(dst, mask netip.Addr) netip.Prefix {
+	if !mask.Is4() {
+		return netip.Prefix{}
+	}
+	m := mask.As4()
+	ones, bits := net.IPMask(m[:]).Size()
+	if bits == 0 {
+		return netip.Prefix{}
+	}
+	return netip.PrefixFrom(dst, ones)
+}
+
+// procDecimalField returns the decimal value of fields[idx], or zero in case
+// the field is absent or invalid.
+func procDecimalField(fields []string, idx int) uint32 {
+	if idx < 0 || idx >= len(fields) {
+		return 0
+	}
+	v, err := strconv.ParseUint(fields[idx], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(v)
+}
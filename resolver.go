@@ -0,0 +1,320 @@
+package gateway
+
+import (
+	"bytes"
+	"net/netip"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Resolver finds default routes and addresses from a specific source, such as
+// the network namespace of another process. The package-level functions use a
+// Resolver reading from the host.
+type Resolver struct {
+	routes    func() (NetRouteList, Provenance, error)
+	addrs     func(name string) ([]InterfaceAddr, error)
+	neighbors func() ([]Neighbor, error)
+
+	mu         sync.Mutex
+	provenance Provenance
+}
+
+// hostResolver is the Resolver used by the package-level functions.
+var hostResolver = &Resolver{
+	routes:    func() (NetRouteList, Provenance, error) { return getRoutes() },
+	addrs:     func(name string) ([]InterfaceAddr, error) { return interfaceAddrs(name) },
+	neighbors: ListNeighbors,
+}
+
+// systemResolver returns a Resolver using the routes and addresses seen by
+// the current process.
+func systemResolver() *Resolver {
+	return hostResolver
+}
+
+// RouteProvenance is like Resolver.Provenance, reporting where routes most
+// recently read by the package-level functions came from.
+func RouteProvenance() Provenance {
+	return systemResolver().Provenance()
+}
+
+// ResolverOption customizes where a Resolver created by NewResolver reads
+// from.
+type ResolverOption func(*resolverOptions)
+
+type resolverOptions struct {
+	root string
+	pid  int
+}
+
+// WithRoot makes the Resolver read procfs from <root>/proc instead of /proc,
+// such as when the host's procfs is mounted into a container. Symlinks
+// leading outside of root are refused. Unless WithPID is provided, routes of
+// the namespace of the host's init process are read.
+//
+// Interfaces are looked up through <root>/proc/<pid>/net/dev rather than
+// sysfs, since /sys/class/net only reflects the namespace sysfs was mounted
+// from.
+func WithRoot(root string) ResolverOption {
+	return func(o *resolverOptions) {
+		o.root = root
+	}
+}
+
+// WithPID makes the Resolver read routes and addresses as seen by the
+// process with the provided PID. When used alongside WithRoot, the PID is
+// the one seen by the procfs mounted below the root.
+func WithPID(pid int) ResolverOption {
+	return func(o *resolverOptions) {
+		o.pid = pid
+	}
+}
+
+// NewResolver returns a Resolver reading routes and addresses from procfs,
+// as customized by the provided options. Without options, the namespace of
+// the current process is read. See ForProcess for the limitations of
+// procfs.
+func NewResolver(opts ...ResolverOption) (*Resolver, error) {
+	o := &resolverOptions{root: "/"}
+	for _, fn := range opts {
+		fn(o)
+	}
+
+	procNet := "proc/self/net"
+	if o.pid != 0 {
+		procNet = path.Join("proc", strconv.Itoa(o.pid), "net")
+	} else if filepath.Clean(o.root) != "/" {
+		procNet = "proc/1/net"
+	}
+	return newProcNetResolver(rootFS{root: o.root}, procNet)
+}
+
+// ForProcess returns a Resolver reading routes and addresses from
+// /proc/<pid>/net, as seen by the process with the provided PID. This works
+// across network namespaces without privileges, but provides neither
+// gateways of tables other than main, nor more than one next hop per route,
+// nor IPv6 neighbors.
+func ForProcess(pid int) (*Resolver, error) {
+	return NewResolver(WithPID(pid))
+}
+
+// newProcNetResolver returns a Resolver reading from procNet, a directory
+// laid out like /proc/net, relative to the root of fs.
+func newProcNetResolver(fs rootFS, procNet string) (*Resolver, error) {
+	if _, err := fs.resolve(path.Join(procNet, "dev")); err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		routes: func() (NetRouteList, Provenance, error) {
+			routes, err := readProcRoutes(fs.ReadFile, procNet)
+			return routes, Provenance{Source: RouteSourceProc}, err
+		},
+		addrs: func(name string) ([]InterfaceAddr, error) {
+			return procInterfaceAddrs(fs.ReadFile, procNet, name)
+		},
+		neighbors: func() ([]Neighbor, error) {
+			data, err := fs.ReadFile(path.Join(procNet, "arp"))
+			if err != nil {
+				return nil, err
+			}
+			return parseProcARP(bytes.NewReader(data))
+		},
+	}, nil
+}
+
+// Routes returns all routes provided by the resolver.
+func (res *Resolver) Routes() (NetRouteList, error) {
+	return res.readRoutes()
+}
+
+// Provenance reports which source provided the routes most recently read by
+// the resolver, and why sources tried before it failed. Resolvers created by
+// NewResolver and ForProcess always read procfs. It is the zero Provenance
+// until routes are first read.
+func (res *Resolver) Provenance() Provenance {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	return res.provenance
+}
+
+// readRoutes returns routes provided by the resolver, recording their
+// provenance.
+func (res *Resolver) readRoutes() (NetRouteList, error) {
+	routes, p, err := res.routes()
+	res.mu.Lock()
+	res.provenance = p
+	res.mu.Unlock()
+	return routes, err
+}
+
+// FindDefaultRoutes is like the package-level FindDefaultRoutes, using routes
+// provided by the resolver.
+func (res *Resolver) FindDefaultRoutes(opts ...DefaultsOption) (NetRouteList, error) {
+	routes, err := res.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	result := routes.FindDefaults(NetRouteKindV4, opts...)
+	result = append(result, routes.FindDefaults(NetRouteKindV6, opts...)...)
+	return result, nil
+}
+
+// FindDefaultGateways is like the package-level FindDefaultGateways, using
+// routes provided by the resolver.
+func (res *Resolver) FindDefaultGateways(opts ...DefaultsOption) ([]netip.Addr, error) {
+	routes, err := res.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	hops, err := defaultHops(routes, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ips []netip.Addr
+	for _, h := range hops {
+		ips = append(ips, netip.MustParseAddr(h.Gateway))
+	}
+
+	return ips, nil
+}
+
+// FindDefaultInterfaces is like the package-level FindDefaultInterfaces,
+// using routes provided by the resolver.
+func (res *Resolver) FindDefaultInterfaces(opts ...DefaultsOption) ([]string, error) {
+	routes, err := res.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	hops, err := defaultHops(routes, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ifsMap []string
+	for _, h := range hops {
+		ifsMap = append(ifsMap, h.Netif)
+	}
+	return unique(ifsMap), nil
+}
+
+// PickDefaultInterface is like the package-level PickDefaultInterface, using
+// routes and addresses provided by the resolver.
+func (res *Resolver) PickDefaultInterface(opts ...DefaultsOption) (string, error) {
+	ifaces, err := res.FindDefaultInterfaces(opts...)
+	if err != nil {
+		return "", err
+	}
+
+	ipCount := map[string]int{}
+
+	for _, name := range ifaces {
+		addrs, err := res.addrs(name)
+		if err != nil {
+			return "", err
+		}
+
+		ipCount[name] = len(addrs)
+	}
+
+	maxLen := 0
+	maxName := ""
+	for k, v := range ipCount {
+		if v > maxLen {
+			maxLen = v
+			maxName = k
+		}
+	}
+
+	return maxName, nil
+}
+
+// FindDefaultIPs is like the package-level FindDefaultIPs, using routes and
+// addresses provided by the resolver.
+func (res *Resolver) FindDefaultIPs(opts ...DefaultsOption) ([]netip.Addr, error) {
+	addrs, err := res.FindDefaultAddrs(opts...)
+	if err != nil {
+		return nil, err
+	}
+	var out []netip.Addr
+	for _, a := range addrs {
+		out = append(out, a.Prefix.Addr().WithZone(a.Netif))
+	}
+	return out, nil
+}
+
+// FindDefaultSourceIPs is like the package-level FindDefaultSourceIPs, using
+// routes and addresses provided by the resolver.
+func (res *Resolver) FindDefaultSourceIPs(opts ...DefaultsOption) ([]netip.Addr, error) {
+	routes, err := res.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	var out []netip.Addr
+	for _, kind := range []NetRouteKind{NetRouteKindV4, NetRouteKindV6} {
+		for _, r := range routes.FindDefaults(kind, opts...) {
+			if !r.Type.Forwards() {
+				// Non-forwarding routes never source any traffic.
+				continue
+			}
+			if r.PrefSrc.IsValid() {
+				out = append(out, r.PrefSrc)
+				continue
+			}
+			for _, h := range r.Hops() {
+				if h.Gateway == "" {
+					continue
+				}
+				gw, err := netip.ParseAddr(h.Gateway)
+				if err != nil {
+					return nil, err
+				}
+				src, err := res.selectSourceAddr(h.Netif, gw)
+				if err != nil {
+					return nil, err
+				}
+				if src.IsValid() {
+					out = append(out, src)
+				}
+			}
+		}
+	}
+	return unique(out), nil
+}
+
+// selectSourceAddr picks the address of the named interface most likely to
+// be selected by the kernel when sending traffic through gateway: global
+// addresses are preferred over link-local ones, and addresses within the
+// gateway's subnet are preferred over others. An invalid address is returned
+// if the interface has no address of the gateway's family.
+func (res *Resolver) selectSourceAddr(netif string, gateway netip.Addr) (netip.Addr, error) {
+	addrs, err := res.addrs(netif)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	gateway = gateway.WithZone("").Unmap()
+
+	var best netip.Addr
+	bestScore := -1
+	for _, v := range addrs {
+		p := v.Prefix
+		a := p.Addr()
+		if a.Is4() != gateway.Is4() {
+			continue
+		}
+		score := 0
+		if !a.IsLinkLocalUnicast() {
+			score += 2
+		}
+		if p.Contains(gateway) {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = a, score
+		}
+	}
+	if best.IsLinkLocalUnicast() && best.Is6() {
+		best = best.WithZone(netif)
+	}
+	return best, nil
+}
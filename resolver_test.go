@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// procRoot assembles a root directory having /proc/<pid>/net populated from
+// fixtures, with /proc/self linking to pid.
+func procRoot(t *testing.T, pid string, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "proc", pid, "net")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.Symlink(pid, filepath.Join(root, "proc", "self")))
+	for name, fixture := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), fixtureFile(t, fixture), 0o644))
+	}
+	return root
+}
+
+func TestProcNetResolver(t *testing.T) {
+	res, err := NewResolver(WithRoot(procRoot(t, "1", map[string]string{
+		"route":      "linuxipv4",
+		"ipv6_route": "linuxipv6",
+		"dev":        "procNetDev",
+		"if_inet6":   "procIfInet6",
+		"fib_trie":   "fibTrieMerged",
+	})))
+	require.NoError(t, err)
+
+	gateways, err := res.FindDefaultGateways()
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Addr{
+		netip.MustParseAddr("192.168.8.1"),
+		netip.MustParseAddr("fe80::20c:29ff:fe97:9e9d"),
+	}, gateways)
+
+	ips, err := res.FindDefaultIPs()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []netip.Addr{
+		netip.MustParseAddr("192.168.8.105"),
+		netip.MustParseAddr("2001:db8::42%wlp4s0"),
+		netip.MustParseAddr("fe80::21a:2bff:fe3c:4d5e%wlp4s0"),
+		netip.MustParseAddr("fdc0:ffee:bab3:f00b::10%ens34"),
+	}, ips)
+
+	addrs, err := res.addrs("docker0")
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("172.17.0.1/16"), addrs[0].Prefix)
+
+	_, err = res.addrs("eth9")
+	var notFound *ErrInterfaceNotFound
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestProcNetResolverWithoutIPv6(t *testing.T) {
+	root := procRoot(t, "4242", map[string]string{
+		"route": "linuxipv4",
+		"dev":   "procNetDev",
+	})
+	res, err := NewResolver(WithRoot(root), WithPID(4242))
+	require.NoError(t, err)
+
+	ifaces, err := res.FindDefaultInterfaces()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wlp4s0"}, ifaces)
+
+	ips, err := res.FindDefaultIPs()
+	require.NoError(t, err)
+	assert.Empty(t, ips)
+}
+
+func TestProcNetResolverMissingRoutes(t *testing.T) {
+	t.Run("IPv4", func(t *testing.T) {
+		res, err := NewResolver(WithRoot(procRoot(t, "1", map[string]string{
+			"ipv6_route": "linuxipv6",
+			"dev":        "procNetDev",
+			"if_inet6":   "procIfInet6",
+		})))
+		require.NoError(t, err)
+		_, err = res.FindDefaultRoutes()
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("IPv6 enabled", func(t *testing.T) {
+		res, err := NewResolver(WithRoot(procRoot(t, "1", map[string]string{
+			"route":    "linuxipv4",
+			"dev":      "procNetDev",
+			"if_inet6": "procIfInet6",
+		})))
+		require.NoError(t, err)
+		_, err = res.FindDefaultRoutes()
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestNewResolver(t *testing.T) {
+	t.Run("Missing process", func(t *testing.T) {
+		_, err := ForProcess(-1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Root without init", func(t *testing.T) {
+		_, err := NewResolver(WithRoot(procRoot(t, "4242", map[string]string{"dev": "procNetDev"})))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Symlink escaping root", func(t *testing.T) {
+		root := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(root, "proc"), 0o755))
+		require.NoError(t, os.Symlink("../../..", filepath.Join(root, "proc", "1")))
+		_, err := NewResolver(WithRoot(root))
+		var escapes *ErrPathEscapesRoot
+		assert.ErrorAs(t, err, &escapes)
+	})
+}
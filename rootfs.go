@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// maxSymlinks bounds how many symlinks are followed while resolving a single
+// path, matching the kernel's limit.
+const maxSymlinks = 40
+
+// rootFS reads files below a root directory, such as the host's filesystem
+// mounted into a container. Symlinks are resolved manually, so that links
+// leading outside of the root are refused instead of being followed into
+// the caller's own filesystem.
+type rootFS struct {
+	root string
+}
+
+// resolve returns the host path of name, a slash-separated path relative to
+// the root. Relative symlinks are followed as long as they stay within the
+// root. Absolute symlinks are refused unless the root is "/", since their
+// targets are meaningless from outside of the root.
+func (fs rootFS) resolve(name string) (string, error) {
+	resolved, err := fs.resolveComponents(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(fs.root, filepath.Join(resolved...)), nil
+}
+
+// resolveComponents is like resolve, returning the components of the path
+// relative to the root, none of which were symlinks.
+func (fs rootFS) resolveComponents(name string) ([]string, error) {
+	var resolved []string
+	pending := strings.Split(name, "/")
+	links := 0
+
+	for len(pending) > 0 {
+		comp := pending[0]
+		pending = pending[1:]
+
+		switch comp {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return nil, &ErrPathEscapesRoot{path: name, root: fs.root}
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		current := filepath.Join(fs.root, filepath.Join(append(resolved, comp)...))
+		fi, err := os.Lstat(current)
+		if err != nil {
+			return nil, err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, comp)
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return nil, &os.PathError{Op: "resolve", Path: name, Err: syscall.ELOOP}
+		}
+		target, err := os.Readlink(current)
+		if err != nil {
+			return nil, err
+		}
+		if path.IsAbs(target) {
+			if filepath.Clean(fs.root) != "/" {
+				return nil, &ErrPathEscapesRoot{path: name, root: fs.root}
+			}
+			resolved = nil
+		}
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+
+	return resolved, nil
+}
+
+// ReadFile reads the named file, relative to the root.
+func (fs rootFS) ReadFile(name string) ([]byte, error) {
+	resolved, err := fs.resolveComponents(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := fs.open(resolved)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// open opens the file made of components below the root, walking them one
+// at a time with openat(2) and refusing to follow symlinks. A component
+// replaced by a symlink after being resolved makes opening fail, instead of
+// leading outside of the root.
+func (fs rootFS) open(components []string) (*os.File, error) {
+	fd, err := syscall.Open(fs.root, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: fs.root, Err: err}
+	}
+	for i, comp := range components {
+		flags := syscall.O_RDONLY | syscall.O_NOFOLLOW | syscall.O_CLOEXEC
+		if i < len(components)-1 {
+			flags |= syscall.O_DIRECTORY
+		}
+		next, err := syscall.Openat(fd, comp, flags, 0)
+		syscall.Close(fd)
+		if err != nil {
+			p := filepath.Join(fs.root, filepath.Join(components[:i+1]...))
+			return nil, &os.PathError{Op: "openat", Path: p, Err: err}
+		}
+		fd = next
+	}
+	return os.NewFile(uintptr(fd), filepath.Join(fs.root, filepath.Join(components...))), nil
+}
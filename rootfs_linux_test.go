@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootFSReplacedComponents(t *testing.T) {
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "route"), []byte("outside"), 0o644))
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "proc", "42", "net"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "proc", "42", "net", "route"), []byte("data"), 0o644))
+	fs := rootFS{root: root}
+
+	resolved, err := fs.resolveComponents("proc/42/net/route")
+	require.NoError(t, err)
+
+	t.Run("File", func(t *testing.T) {
+		route := filepath.Join(root, "proc", "42", "net", "route")
+		require.NoError(t, os.Rename(route, route+".orig"))
+		require.NoError(t, os.Symlink(filepath.Join(outside, "route"), route))
+		t.Cleanup(func() {
+			require.NoError(t, os.Remove(route))
+			require.NoError(t, os.Rename(route+".orig", route))
+		})
+
+		_, err := fs.open(resolved)
+		assert.ErrorIs(t, err, syscall.ELOOP)
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		dir := filepath.Join(root, "proc", "42", "net")
+		require.NoError(t, os.Rename(dir, dir+".orig"))
+		require.NoError(t, os.Symlink(outside, dir))
+
+		_, err := fs.open(resolved)
+		assert.ErrorIs(t, err, syscall.ENOTDIR)
+	})
+}
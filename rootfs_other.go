@@ -0,0 +1,15 @@
+//go:build !linux
+
+package gateway
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// open opens the file made of components below the root. Only Linux
+// provides the /proc files read through rootFS, so no effort is made to
+// guard against components being replaced after resolution.
+func (fs rootFS) open(components []string) (*os.File, error) {
+	return os.Open(filepath.Join(fs.root, filepath.Join(components...)))
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootFS(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "proc", "42", "net"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "proc", "42", "net", "route"), []byte("data"), 0o644))
+	require.NoError(t, os.Symlink("42", filepath.Join(root, "proc", "self")))
+	require.NoError(t, os.Symlink("self/net", filepath.Join(root, "proc", "net")))
+	require.NoError(t, os.Symlink("../..", filepath.Join(root, "proc", "up")))
+	require.NoError(t, os.Symlink("/etc", filepath.Join(root, "proc", "abs")))
+	require.NoError(t, os.Symlink("loop", filepath.Join(root, "proc", "loop")))
+	fs := rootFS{root: root}
+
+	t.Run("Relative symlinks", func(t *testing.T) {
+		data, err := fs.ReadFile("proc/net/route")
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+
+		p, err := fs.resolve("/proc/self/../net/./route")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "proc", "42", "net", "route"), p)
+	})
+
+	t.Run("Escaping symlinks", func(t *testing.T) {
+		var escapes *ErrPathEscapesRoot
+		_, err := fs.ReadFile("proc/up/etc/passwd")
+		assert.ErrorAs(t, err, &escapes)
+		_, err = fs.ReadFile("proc/abs/passwd")
+		assert.ErrorAs(t, err, &escapes)
+		_, err = fs.ReadFile("../etc/passwd")
+		assert.ErrorAs(t, err, &escapes)
+	})
+
+	t.Run("Symlink loop", func(t *testing.T) {
+		_, err := fs.ReadFile("proc/loop")
+		assert.ErrorIs(t, err, syscall.ELOOP)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := fs.ReadFile("proc/net/ipv6_route")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
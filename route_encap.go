@@ -0,0 +1,109 @@
+package gateway
+
+import "net/netip"
+
+// EncapType identifies the lightweight tunnel encapsulation applied by a
+// route ("encap" in `ip route`). Values mirror the kernel's
+// LWTUNNEL_ENCAP_* constants.
+type EncapType uint16
+
+const (
+	EncapTypeNone EncapType = iota
+	EncapTypeMPLS
+	EncapTypeIP
+	EncapTypeILA
+	EncapTypeIP6
+	EncapTypeSeg6
+	EncapTypeBPF
+	EncapTypeSeg6Local
+	EncapTypeRPL
+	EncapTypeIOAM6
+	EncapTypeXFRM
+)
+
+var encapTypeNames = [...]string{
+	EncapTypeNone:      "none",
+	EncapTypeMPLS:      "mpls",
+	EncapTypeIP:        "ip",
+	EncapTypeILA:       "ila",
+	EncapTypeIP6:       "ip6",
+	EncapTypeSeg6:      "seg6",
+	EncapTypeBPF:       "bpf",
+	EncapTypeSeg6Local: "seg6local",
+	EncapTypeRPL:       "rpl",
+	EncapTypeIOAM6:     "ioam6",
+	EncapTypeXFRM:      "xfrm",
+}
+
+func (t EncapType) String() string {
+	if int(t) < len(encapTypeNames) {
+		return encapTypeNames[t]
+	}
+	return "unknown"
+}
+
+// Seg6Mode indicates how an SRv6 segment list is applied to traffic.
+// Values mirror the kernel's SEG6_IPTUN_MODE_* constants.
+type Seg6Mode int32
+
+const (
+	Seg6ModeInline Seg6Mode = iota
+	Seg6ModeEncap
+	Seg6ModeL2Encap
+	Seg6ModeEncapRed
+	Seg6ModeL2EncapRed
+)
+
+var seg6ModeNames = [...]string{
+	Seg6ModeInline:     "inline",
+	Seg6ModeEncap:      "encap",
+	Seg6ModeL2Encap:    "l2encap",
+	Seg6ModeEncapRed:   "encap.red",
+	Seg6ModeL2EncapRed: "l2encap.red",
+}
+
+func (m Seg6Mode) String() string {
+	if m >= 0 && int(m) < len(seg6ModeNames) {
+		return seg6ModeNames[m]
+	}
+	return "unknown"
+}
+
+// RouteEncap describes the encapsulation applied to traffic using a route.
+// Only fields relevant to Type are set.
+type RouteEncap struct {
+	Type EncapType
+
+	// Labels is the MPLS label stack pushed by EncapTypeMPLS, outermost
+	// label first.
+	Labels []uint32
+
+	// Seg6Mode and Segments describe the SRv6 segment list used by
+	// EncapTypeSeg6, in the order segments are visited.
+	Seg6Mode Seg6Mode
+	Segments []netip.Addr
+
+	// TunnelID, TunnelSrc, TunnelDst, TTL and TOS describe the tunnel
+	// endpoint used by EncapTypeIP and EncapTypeIP6. For the latter, TTL and
+	// TOS hold the hop limit and traffic class, respectively.
+	TunnelID  uint64
+	TunnelSrc netip.Addr
+	TunnelDst netip.Addr
+	TTL       uint8
+	TOS       uint8
+}
+
+// IsEncapsulated returns whether traffic using the route, or any of its next
+// hops, is encapsulated. For those routes, Gateway does not reflect where
+// traffic is actually delivered.
+func (n NetRoute) IsEncapsulated() bool {
+	if n.Encap != nil {
+		return true
+	}
+	for _, h := range n.NextHops {
+		if h.Encap != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import "time"
+
+// RouteMetric identifies a per-route metric. Values mirror the kernel's
+// RTAX_* constants.
+type RouteMetric uint8
+
+const (
+	RouteMetricMTU              RouteMetric = 2
+	RouteMetricWindow           RouteMetric = 3
+	RouteMetricRTT              RouteMetric = 4
+	RouteMetricRTTVar           RouteMetric = 5
+	RouteMetricSSThresh         RouteMetric = 6
+	RouteMetricCWND             RouteMetric = 7
+	RouteMetricAdvMSS           RouteMetric = 8
+	RouteMetricReordering       RouteMetric = 9
+	RouteMetricHopLimit         RouteMetric = 10
+	RouteMetricInitCWND         RouteMetric = 11
+	RouteMetricFeatures         RouteMetric = 12
+	RouteMetricRTOMin           RouteMetric = 13
+	RouteMetricInitRWND         RouteMetric = 14
+	RouteMetricQuickAck         RouteMetric = 15
+	RouteMetricCCAlgo           RouteMetric = 16
+	RouteMetricFastOpenNoCookie RouteMetric = 17
+)
+
+// RouteMetrics holds per-route metrics, as set by `ip route ... mtu 1400
+// initcwnd 10`. Zero values indicate the metric is not set.
+type RouteMetrics struct {
+	MTU              uint32
+	Window           uint32
+	RTT              time.Duration
+	RTTVar           time.Duration
+	SSThresh         uint32
+	CWND             uint32
+	AdvMSS           uint32
+	Reordering       uint32
+	HopLimit         uint32
+	InitCWND         uint32
+	Features         uint32
+	RTOMin           time.Duration
+	InitRWND         uint32
+	QuickAck         uint32
+	CCAlgo           string
+	FastOpenNoCookie uint32
+
+	// Locked is a bitmask of metrics locked with the "lock" keyword,
+	// preventing the kernel from updating them (e.g. through path MTU
+	// discovery).
+	Locked uint32
+}
+
+// IsLocked returns whether the provided metric is locked.
+func (m RouteMetrics) IsLocked(metric RouteMetric) bool {
+	return m.Locked&(1<<metric) != 0
+}
@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Clock provides time to watchers, allowing tests to control it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+const (
+	defaultPollInterval   = 5 * time.Second
+	defaultPollDebounce   = 250 * time.Millisecond
+	defaultPollMaxBackoff = time.Minute
+	// pollMaxFailures is how many consecutive reads may fail before a
+	// poller gives up.
+	pollMaxFailures = 5
+)
+
+// PollOption customizes how PollRoutes reads routes.
+type PollOption func(*pollOptions)
+
+type pollOptions struct {
+	interval   time.Duration
+	jitter     time.Duration
+	debounce   time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	clock      Clock
+}
+
+func newPollOptions(opts []PollOption) *pollOptions {
+	o := &pollOptions{
+		interval:   defaultPollInterval,
+		debounce:   defaultPollDebounce,
+		maxBackoff: defaultPollMaxBackoff,
+		clock:      systemClock{},
+	}
+	for _, fn := range opts {
+		fn(o)
+	}
+	// Non-positive durations would make pollers spin.
+	if o.interval <= 0 {
+		o.interval = defaultPollInterval
+	}
+	if o.maxBackoff <= 0 {
+		o.maxBackoff = defaultPollMaxBackoff
+	}
+	if o.minBackoff <= 0 {
+		o.minBackoff = o.interval
+	}
+	o.maxBackoff = max(o.maxBackoff, o.minBackoff)
+	return o
+}
+
+// WithPollInterval sets how often routes are read. It defaults to 5 seconds,
+// which is also used for non-positive intervals.
+func WithPollInterval(d time.Duration) PollOption {
+	return func(o *pollOptions) {
+		o.interval = d
+	}
+}
+
+// WithJitter adds a random delay of up to d to each interval, so that many
+// watchers started at once don't read routes in lockstep.
+func WithJitter(d time.Duration) PollOption {
+	return func(o *pollOptions) {
+		o.jitter = d
+	}
+}
+
+// WithDebounce sets how long to wait for routes to settle once a change is
+// seen, so that a burst of changes, such as a VPN connecting, is reported at
+// once. Routes are read again every d until two reads match, for at most an
+// interval. It defaults to 250ms; zero reports changes as soon as they are
+// seen.
+func WithDebounce(d time.Duration) PollOption {
+	return func(o *pollOptions) {
+		o.debounce = d
+	}
+}
+
+// WithBackoff sets how long to wait after failing to read routes. The delay
+// starts at initial, and doubles on each consecutive failure, up to max. It
+// defaults to the interval, up to a minute; non-positive durations select
+// those defaults.
+func WithBackoff(initial, max time.Duration) PollOption {
+	return func(o *pollOptions) {
+		o.minBackoff, o.maxBackoff = initial, max
+	}
+}
+
+// WithClock makes the watcher use the provided clock instead of the system's.
+func WithClock(c Clock) PollOption {
+	return func(o *pollOptions) {
+		o.clock = c
+	}
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func (o *pollOptions) sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-o.clock.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// wait returns how long to wait before the next read, given the current
+// backoff, which is zero after successful reads.
+func (o *pollOptions) wait(backoff time.Duration) time.Duration {
+	if backoff > 0 {
+		return backoff
+	}
+	if o.jitter > 0 {
+		return o.interval + time.Duration(rand.Int63n(int64(o.jitter)))
+	}
+	return o.interval
+}
+
+// backoff returns the backoff following a failed read.
+func (o *pollOptions) backoff(prev time.Duration) time.Duration {
+	return min(max(prev*2, o.minBackoff), o.maxBackoff)
+}
+
+// PollRoutes is like Resolver.PollRoutes, reading routes of the host.
+func PollRoutes(ctx context.Context, opts ...PollOption) (*RouteWatcher, error) {
+	return systemResolver().PollRoutes(ctx, opts...)
+}
+
+// PollRoutes returns a RouteWatcher delivering changes of the routes provided
+// by the resolver, which are read periodically. This works with any backend,
+// at the cost of latency; see WatchRoutes for real-time notifications on
+// Linux. Routes present when the watcher starts are not reported.
+//
+// Failing to read routes initially is reported right away. Later failures
+// are retried with backoff; the watcher stops once five reads in a row
+// failed, reporting the last error through Err. It also stops once ctx is
+// done.
+func (res *Resolver) PollRoutes(ctx context.Context, opts ...PollOption) (*RouteWatcher, error) {
+	o := newPollOptions(opts)
+	routes, err := res.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	w := newRouteWatcher(routes)
+	go w.poll(ctx, res.readRoutes, o, newRouteState(routes))
+	return w, nil
+}
+
+func (w *RouteWatcher) poll(ctx context.Context, read func() (NetRouteList, error), o *pollOptions, state routeState) {
+	defer close(w.events)
+	var backoff time.Duration
+	failures := 0
+	for {
+		if !o.sleep(ctx, o.wait(backoff)) {
+			return
+		}
+
+		routes, err := read()
+		if err != nil {
+			if failures++; failures >= pollMaxFailures {
+				w.err = err
+				return
+			}
+			backoff = o.backoff(backoff)
+			continue
+		}
+		backoff, failures = 0, 0
+
+		if o.debounce > 0 && !state.equal(routes) {
+			deadline := o.clock.Now().Add(o.interval)
+			for o.clock.Now().Before(deadline) {
+				if !o.sleep(ctx, o.debounce) {
+					return
+				}
+				next, err := read()
+				if err != nil {
+					break
+				}
+				settled := newRouteState(routes).equal(next)
+				routes = next
+				if settled {
+					break
+				}
+			}
+		}
+
+		if !w.send(ctx, state.sync(routes)) {
+			return
+		}
+	}
+}
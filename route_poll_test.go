@@ -0,0 +1,196 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock whose time only moves through Advance. Durations
+// waited for are reported on requests.
+type fakeClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	timers   []fakeTimer
+	requests chan time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		requests: make(chan time.Duration, 16),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.mu.Unlock()
+	c.requests <- d
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+		} else {
+			t.ch <- c.now
+		}
+	}
+	c.timers = pending
+}
+
+// elapse waits for the watcher to sleep, and wakes it up, returning how long
+// it meant to sleep.
+func (c *fakeClock) elapse(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.requests:
+		c.Advance(d)
+		return d
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "watcher is not sleeping")
+	}
+	return 0
+}
+
+func TestPollRoutes(t *testing.T) {
+	gw := NetRoute{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0"}
+	moved := gw
+	moved.Gateway = "10.0.0.2"
+	vpn := NetRoute{Kind: NetRouteKindV4, Destination: "10.8.0.0/16", Flags: "U", Netif: "tun0"}
+
+	// Each read is answered by the test, so that it happens at a known time.
+	type result struct {
+		routes NetRouteList
+		err    error
+	}
+	results := make(chan result, 1)
+	res := &Resolver{routes: func() (NetRouteList, Provenance, error) {
+		r := <-results
+		return r.routes, Provenance{}, r.err
+	}}
+	clock := newFakeClock()
+	// step expects the watcher to sleep for d, before reading routes.
+	step := func(d time.Duration, routes NetRouteList, err error) {
+		t.Helper()
+		assert.Equal(t, d, clock.elapse(t))
+		results <- result{routes, err}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results <- result{routes: NetRouteList{gw}}
+	w, err := res.PollRoutes(ctx,
+		WithClock(clock),
+		WithPollInterval(10*time.Second),
+		WithDebounce(time.Second),
+		WithBackoff(2*time.Second, 5*time.Second))
+	require.NoError(t, err)
+
+	step(10*time.Second, NetRouteList{gw}, nil)
+
+	// A burst of changes is reported once routes settle.
+	step(10*time.Second, NetRouteList{moved}, nil)
+	step(time.Second, NetRouteList{moved, vpn}, nil)
+	step(time.Second, NetRouteList{moved, vpn}, nil)
+	assert.ElementsMatch(t, []RouteEvent{
+		{Type: RouteChanged, Route: moved, Previous: gw},
+		{Type: RouteAdded, Route: vpn},
+	}, []RouteEvent{<-w.Events(), <-w.Events()})
+
+	// Failures are retried with backoff.
+	unavailable := errors.New("unavailable")
+	step(10*time.Second, nil, unavailable)
+	step(2*time.Second, nil, unavailable)
+	step(4*time.Second, nil, unavailable)
+	step(5*time.Second, nil, unavailable)
+	step(5*time.Second, NetRouteList{moved}, nil)
+	step(time.Second, NetRouteList{moved}, nil)
+	assert.Equal(t, RouteEvent{Type: RouteRemoved, Route: vpn}, <-w.Events())
+	assert.Equal(t, 10*time.Second, clock.elapse(t))
+
+	cancel()
+	results <- result{routes: NetRouteList{moved}}
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+	assert.NoError(t, w.Err())
+}
+
+func TestPollRoutesJitter(t *testing.T) {
+	res := &Resolver{routes: func() (NetRouteList, Provenance, error) { return nil, Provenance{}, nil }}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock()
+	_, err := res.PollRoutes(ctx, WithClock(clock), WithPollInterval(10*time.Second), WithJitter(3*time.Second))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		d := clock.elapse(t)
+		assert.GreaterOrEqual(t, d, 10*time.Second)
+		assert.Less(t, d, 13*time.Second)
+	}
+}
+
+func TestPollRoutesInitialError(t *testing.T) {
+	res := &Resolver{routes: func() (NetRouteList, Provenance, error) {
+		return nil, Provenance{}, errors.New("unavailable")
+	}}
+	_, err := res.PollRoutes(context.Background())
+	assert.Error(t, err)
+}
+
+func TestPollRoutesPersistentErrors(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	reads := 0
+	res := &Resolver{routes: func() (NetRouteList, Provenance, error) {
+		reads++
+		if reads == 1 {
+			return nil, Provenance{}, nil
+		}
+		return nil, Provenance{}, unavailable
+	}}
+	clock := newFakeClock()
+	w, err := res.PollRoutes(context.Background(), WithClock(clock), WithPollInterval(10*time.Second))
+	require.NoError(t, err)
+
+	for i := 0; i < pollMaxFailures; i++ {
+		clock.elapse(t)
+	}
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+	assert.Equal(t, unavailable, w.Err())
+	assert.Equal(t, 1+pollMaxFailures, reads)
+}
+
+func TestPollOptionsNonPositive(t *testing.T) {
+	o := newPollOptions([]PollOption{WithPollInterval(0), WithBackoff(-time.Second, 0)})
+	assert.Equal(t, defaultPollInterval, o.interval)
+	assert.Equal(t, defaultPollInterval, o.backoff(0))
+	assert.Equal(t, defaultPollMaxBackoff, o.backoff(defaultPollMaxBackoff))
+
+	o = newPollOptions([]PollOption{WithPollInterval(-time.Second)})
+	assert.Equal(t, defaultPollInterval, o.wait(0))
+}
@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// RouteProtocol identifies the origin of a route, such as the kernel itself,
+// a DHCP client, or a routing daemon. Values mirror the kernel's RTPROT_*
+// constants, and may be extended by /etc/iproute2/rt_protos.
+type RouteProtocol uint8
+
+const (
+	// RouteProtocolUnspec indicates the origin is unknown, either because
+	// the backend does not report it, or the route didn't specify one.
+	RouteProtocolUnspec    RouteProtocol = 0
+	RouteProtocolRedirect  RouteProtocol = 1
+	RouteProtocolKernel    RouteProtocol = 2
+	RouteProtocolBoot      RouteProtocol = 3
+	RouteProtocolStatic    RouteProtocol = 4
+	RouteProtocolGated     RouteProtocol = 8
+	RouteProtocolRA        RouteProtocol = 9
+	RouteProtocolMRT       RouteProtocol = 10
+	RouteProtocolZebra     RouteProtocol = 11
+	RouteProtocolBird      RouteProtocol = 12
+	RouteProtocolDNRouted  RouteProtocol = 13
+	RouteProtocolXORP      RouteProtocol = 14
+	RouteProtocolNTK       RouteProtocol = 15
+	RouteProtocolDHCP      RouteProtocol = 16
+	RouteProtocolMRouted   RouteProtocol = 17
+	RouteProtocolKeepalive RouteProtocol = 18
+	RouteProtocolBabel     RouteProtocol = 42
+	RouteProtocolOpenR     RouteProtocol = 99
+	RouteProtocolBGP       RouteProtocol = 186
+	RouteProtocolISIS      RouteProtocol = 187
+	RouteProtocolOSPF      RouteProtocol = 188
+	RouteProtocolRIP       RouteProtocol = 189
+	RouteProtocolEIGRP     RouteProtocol = 192
+)
+
+var builtinRouteProtocolNames = map[RouteProtocol]string{
+	RouteProtocolUnspec:    "unspec",
+	RouteProtocolRedirect:  "redirect",
+	RouteProtocolKernel:    "kernel",
+	RouteProtocolBoot:      "boot",
+	RouteProtocolStatic:    "static",
+	RouteProtocolGated:     "gated",
+	RouteProtocolRA:        "ra",
+	RouteProtocolMRT:       "mrt",
+	RouteProtocolZebra:     "zebra",
+	RouteProtocolBird:      "bird",
+	RouteProtocolDNRouted:  "dnrouted",
+	RouteProtocolXORP:      "xorp",
+	RouteProtocolNTK:       "ntk",
+	RouteProtocolDHCP:      "dhcp",
+	RouteProtocolMRouted:   "mrouted",
+	RouteProtocolKeepalive: "keepalived",
+	RouteProtocolBabel:     "babel",
+	RouteProtocolOpenR:     "openr",
+	RouteProtocolBGP:       "bgp",
+	RouteProtocolISIS:      "isis",
+	RouteProtocolOSPF:      "ospf",
+	RouteProtocolRIP:       "rip",
+	RouteProtocolEIGRP:     "eigrp",
+}
+
+var routeProtocolNames = sync.OnceValue(func() map[RouteProtocol]string {
+	names := make(map[RouteProtocol]string, len(builtinRouteProtocolNames))
+	for k, v := range builtinRouteProtocolNames {
+		names[k] = v
+	}
+	for k, v := range loadIPRoute2Names("rt_protos") {
+		if k <= 0xFF {
+			names[RouteProtocol(k)] = v
+		}
+	}
+	return names
+})
+
+// String returns the name of the protocol as configured in
+// /etc/iproute2/rt_protos, or its number in case it has no name.
+func (p RouteProtocol) String() string {
+	if name, ok := routeProtocolNames()[p]; ok {
+		return name
+	}
+	return strconv.Itoa(int(p))
+}
+
+// ParseRouteProtocol returns the protocol identified by the provided name,
+// as listed in /etc/iproute2/rt_protos, or by its number.
+func ParseRouteProtocol(name string) (RouteProtocol, error) {
+	for k, v := range routeProtocolNames() {
+		if v == name {
+			return k, nil
+		}
+	}
+	if v, err := strconv.ParseUint(name, 0, 8); err == nil {
+		return RouteProtocol(v), nil
+	}
+	return 0, fmt.Errorf("unknown route protocol %q", name)
+}
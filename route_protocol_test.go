@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteProtocol(t *testing.T) {
+	t.Run("Names database", func(t *testing.T) {
+		f, err := os.Open(fixtureFilePath("rtProtos"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		names := map[uint32]string{}
+		parseIPRoute2Names(f, names)
+		assert.Equal(t, map[uint32]string{
+			0:   "unspec",
+			2:   "kernel",
+			3:   "boot",
+			4:   "static",
+			16:  "dhcp",
+			201: "wgvpn",
+		}, names)
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		p, err := ParseRouteProtocol("dhcp")
+		require.NoError(t, err)
+		assert.Equal(t, RouteProtocolDHCP, p)
+
+		p, err = ParseRouteProtocol("201")
+		require.NoError(t, err)
+		assert.Equal(t, RouteProtocol(201), p)
+
+		_, err = ParseRouteProtocol("nope")
+		assert.Error(t, err)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		setRoutes(t, NetRouteList{
+			{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0", Protocol: RouteProtocolDHCP},
+			{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.8.0.1", Netif: "wg0", Protocol: RouteProtocol(201)},
+		})
+
+		ifaces, err := FindDefaultInterfaces(ExcludeProtocols(201))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"eth0"}, ifaces)
+
+		gateways, err := FindDefaultGateways(WithProtocols(201))
+		require.NoError(t, err)
+		require.Len(t, gateways, 1)
+		assert.Equal(t, "10.8.0.1", gateways[0].String())
+	})
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"strconv"
+	"sync"
+)
+
+// RouteScope indicates the distance to the destination of a route. Values
+// mirror the kernel's RT_SCOPE_* constants, and may be extended by
+// /etc/iproute2/rt_scopes.
+type RouteScope uint8
+
+const (
+	// RouteScopeUniverse indicates a route to a destination more than one
+	// hop away. iproute2 names it "global".
+	RouteScopeUniverse RouteScope = 0
+	RouteScopeSite     RouteScope = 200
+	// RouteScopeLink indicates a destination on a directly attached link.
+	RouteScopeLink RouteScope = 253
+	// RouteScopeHost indicates a destination on the local host.
+	RouteScopeHost    RouteScope = 254
+	RouteScopeNowhere RouteScope = 255
+)
+
+var builtinRouteScopeNames = map[RouteScope]string{
+	RouteScopeUniverse: "global",
+	RouteScopeSite:     "site",
+	RouteScopeLink:     "link",
+	RouteScopeHost:     "host",
+	RouteScopeNowhere:  "nowhere",
+}
+
+var routeScopeNames = sync.OnceValue(func() map[RouteScope]string {
+	names := make(map[RouteScope]string, len(builtinRouteScopeNames))
+	for k, v := range builtinRouteScopeNames {
+		names[k] = v
+	}
+	for k, v := range loadIPRoute2Names("rt_scopes") {
+		if k <= 0xFF {
+			names[RouteScope(k)] = v
+		}
+	}
+	return names
+})
+
+// String returns the name of the scope as configured in
+// /etc/iproute2/rt_scopes, or its number in case it has no name.
+func (s RouteScope) String() string {
+	if name, ok := routeScopeNames()[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// parseRouteScope returns the scope identified by the provided name, as
+// listed in /etc/iproute2/rt_scopes, or by its number.
+func parseRouteScope(name string) (RouteScope, bool) {
+	for k, v := range routeScopeNames() {
+		if v == name {
+			return k, true
+		}
+	}
+	v, err := strconv.ParseUint(name, 0, 8)
+	return RouteScope(v), err == nil
+}
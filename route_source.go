@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// RouteSource identifies a backend routes can be read from.
+type RouteSource uint8
+
+const (
+	// RouteSourceNetlink reads routes of all tables through rtnetlink.
+	RouteSourceNetlink RouteSource = iota + 1
+	// RouteSourceProc reads /proc/net/route, /proc/net/ipv6_route and
+	// /proc/net/fib_trie.
+	RouteSourceProc
+	// RouteSourceIPJSON runs `ip -j -d route show table all`.
+	RouteSourceIPJSON
+	// RouteSourceIPText runs `ip route show table all`, for versions of ip
+	// lacking JSON output.
+	RouteSourceIPText
+	// RouteSourceNetstat runs `netstat -rn`.
+	RouteSourceNetstat
+)
+
+var routeSourceNames = [...]string{
+	RouteSourceNetlink: "netlink",
+	RouteSourceProc:    "proc",
+	RouteSourceIPJSON:  "ip-json",
+	RouteSourceIPText:  "ip",
+	RouteSourceNetstat: "netstat",
+}
+
+func (s RouteSource) String() string {
+	if s > 0 && int(s) < len(routeSourceNames) {
+		return routeSourceNames[s]
+	}
+	return "unknown"
+}
+
+// routeSources holds the sources available on this platform.
+var routeSources = map[RouteSource]func(ctx context.Context) (NetRouteList, error){}
+
+// defaultRouteChain is the chain used by the package-level functions.
+var defaultRouteChain RouteChain
+
+// RouteChain is an ordered list of route sources. Sources are tried in turn,
+// until one of them succeeds.
+type RouteChain []RouteSource
+
+// DefaultRouteChain returns the chain used by the package-level functions on
+// this platform. On Linux, rtnetlink is tried first, followed by procfs, ip
+// and netstat.
+func DefaultRouteChain() RouteChain {
+	return slices.Clone(defaultRouteChain)
+}
+
+// SourceFailure describes why a source of a RouteChain failed.
+type SourceFailure struct {
+	Source RouteSource
+	Err    error
+}
+
+// Provenance reports which source of a RouteChain provided routes, and why
+// sources tried before it failed.
+type Provenance struct {
+	Source   RouteSource
+	Failures []SourceFailure
+}
+
+// String returns a summary such as "proc (netlink: permission denied)".
+func (p Provenance) String() string {
+	if len(p.Failures) == 0 {
+		return p.Source.String()
+	}
+	return p.Source.String() + " (" + describeFailures(p.Failures) + ")"
+}
+
+func describeFailures(failures []SourceFailure) string {
+	v := make([]string, len(failures))
+	for i, f := range failures {
+		v[i] = f.Source.String() + ": " + f.Err.Error()
+	}
+	return strings.Join(v, "; ")
+}
+
+// Routes returns routes provided by the first source of the chain which
+// succeeds, along with the provenance of those routes. Sources unavailable on
+// this platform fail with ErrNotImplemented. ErrNoRouteSource is returned
+// when all of them fail.
+func (c RouteChain) Routes(ctx context.Context) (NetRouteList, Provenance, error) {
+	var p Provenance
+	for _, source := range c {
+		if err := ctx.Err(); err != nil {
+			p.Failures = append(p.Failures, SourceFailure{Source: source, Err: err})
+			break
+		}
+		fn, ok := routeSources[source]
+		if !ok {
+			p.Failures = append(p.Failures, SourceFailure{Source: source, Err: &ErrNotImplemented{}})
+			continue
+		}
+		routes, err := fn(ctx)
+		if err != nil {
+			p.Failures = append(p.Failures, SourceFailure{Source: source, Err: err})
+			continue
+		}
+		p.Source = source
+		return routes, p, nil
+	}
+	return nil, p, &ErrNoRouteSource{failures: p.Failures}
+}
+
+// Resolver returns a Resolver reading routes through the chain, and
+// addresses of interfaces as seen by the current process.
+func (c RouteChain) Resolver() *Resolver {
+	return &Resolver{
+		routes: func() (NetRouteList, Provenance, error) {
+			return c.Routes(context.Background())
+		},
+		addrs:     func(name string) ([]InterfaceAddr, error) { return interfaceAddrs(name) },
+		neighbors: ListNeighbors,
+	}
+}
@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setRouteSource replaces the implementation of a route source, removing it
+// when fn is nil.
+func setRouteSource(t *testing.T, source RouteSource, fn func(context.Context) (NetRouteList, error)) {
+	t.Helper()
+	prev, ok := routeSources[source]
+	if fn == nil {
+		delete(routeSources, source)
+	} else {
+		routeSources[source] = fn
+	}
+	t.Cleanup(func() {
+		if ok {
+			routeSources[source] = prev
+		} else {
+			delete(routeSources, source)
+		}
+	})
+}
+
+func TestRouteChain(t *testing.T) {
+	denied := func(context.Context) (NetRouteList, error) { return nil, os.ErrPermission }
+	found := func(context.Context) (NetRouteList, error) {
+		return NetRouteList{{Kind: NetRouteKindV4, Destination: "default", Flags: "UG", Gateway: "10.0.0.1"}}, nil
+	}
+
+	t.Run("Fallback", func(t *testing.T) {
+		setRouteSource(t, RouteSourceNetlink, denied)
+		setRouteSource(t, RouteSourceProc, nil)
+		setRouteSource(t, RouteSourceIPText, found)
+		setRouteSource(t, RouteSourceNetstat, denied)
+
+		chain := RouteChain{RouteSourceNetlink, RouteSourceProc, RouteSourceIPText, RouteSourceNetstat}
+		routes, p, err := chain.Routes(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, routes, 1)
+		assert.Equal(t, RouteSourceIPText, p.Source)
+		require.Len(t, p.Failures, 2)
+		assert.Equal(t, RouteSourceNetlink, p.Failures[0].Source)
+		assert.ErrorIs(t, p.Failures[0].Err, os.ErrPermission)
+		var notImplemented *ErrNotImplemented
+		assert.ErrorAs(t, p.Failures[1].Err, &notImplemented)
+		assert.Equal(t, "ip (netlink: permission denied; proc: "+notImplemented.Error()+")", p.String())
+
+		res := chain.Resolver()
+		assert.Equal(t, Provenance{}, res.Provenance())
+		gateways, err := res.FindDefaultGateways()
+		require.NoError(t, err)
+		assert.Equal(t, []netip.Addr{netip.MustParseAddr("10.0.0.1")}, gateways)
+		assert.Equal(t, p, res.Provenance())
+	})
+
+	t.Run("Package-level functions", func(t *testing.T) {
+		chain := DefaultRouteChain()
+		for _, source := range chain[:len(chain)-1] {
+			setRouteSource(t, source, denied)
+		}
+		last := chain[len(chain)-1]
+		setRouteSource(t, last, found)
+
+		gateways, err := FindDefaultGateways()
+		require.NoError(t, err)
+		assert.Equal(t, []netip.Addr{netip.MustParseAddr("10.0.0.1")}, gateways)
+		p := RouteProvenance()
+		assert.Equal(t, last, p.Source)
+		assert.Len(t, p.Failures, len(chain)-1)
+		for _, f := range p.Failures {
+			assert.ErrorIs(t, f.Err, os.ErrPermission)
+		}
+	})
+
+	t.Run("All failed", func(t *testing.T) {
+		setRouteSource(t, RouteSourceNetlink, denied)
+		setRouteSource(t, RouteSourceProc, denied)
+
+		_, p, err := RouteChain{RouteSourceNetlink, RouteSourceProc}.Routes(context.Background())
+		var noSource *ErrNoRouteSource
+		require.ErrorAs(t, err, &noSource)
+		assert.ErrorIs(t, err, os.ErrPermission)
+		assert.Len(t, p.Failures, 2)
+
+		_, _, err = RouteChain{}.Routes(context.Background())
+		assert.ErrorAs(t, err, &noSource)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		setRouteSource(t, RouteSourceNetlink, func(context.Context) (NetRouteList, error) {
+			return nil, errors.New("unavailable")
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, p, err := RouteChain{RouteSourceNetlink, RouteSourceProc}.Routes(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Len(t, p.Failures, 1)
+	})
+}
@@ -0,0 +1,36 @@
+package gateway
+
+import (
+	"strconv"
+	"sync"
+)
+
+var builtinRouteTableNames = map[uint32]string{
+	0:               "unspec",
+	253:             "default",
+	routeTableMain:  "main",
+	routeTableLocal: "local",
+}
+
+var routeTableNames = sync.OnceValue(func() map[uint32]string {
+	names := make(map[uint32]string, len(builtinRouteTableNames))
+	for k, v := range builtinRouteTableNames {
+		names[k] = v
+	}
+	for k, v := range loadIPRoute2Names("rt_tables") {
+		names[k] = v
+	}
+	return names
+})
+
+// parseRouteTable returns the routing table identified by the provided name,
+// as listed in /etc/iproute2/rt_tables, or by its number.
+func parseRouteTable(name string) (uint32, bool) {
+	for k, v := range routeTableNames() {
+		if v == name {
+			return k, true
+		}
+	}
+	v, err := strconv.ParseUint(name, 0, 32)
+	return uint32(v), err == nil
+}
@@ -0,0 +1,69 @@
+package gateway
+
+// RouteType represents the type of a route, as reported by `ip route`.
+// Values mirror the kernel's RTN_* constants.
+type RouteType uint8
+
+const (
+	// RouteTypeUnspec indicates the backend was unable to report a type.
+	RouteTypeUnspec RouteType = iota
+	// RouteTypeUnicast indicates a regular route to a gateway or directly
+	// connected network.
+	RouteTypeUnicast
+	// RouteTypeLocal indicates the destination is assigned to this host.
+	RouteTypeLocal
+	// RouteTypeBroadcast indicates the destination is a broadcast address.
+	RouteTypeBroadcast
+	// RouteTypeAnycast indicates the destination is a local anycast address.
+	RouteTypeAnycast
+	// RouteTypeMulticast indicates a route used for multicast traffic.
+	RouteTypeMulticast
+	// RouteTypeBlackhole indicates traffic is silently discarded.
+	RouteTypeBlackhole
+	// RouteTypeUnreachable indicates traffic is rejected with an
+	// unreachable error.
+	RouteTypeUnreachable
+	// RouteTypeProhibit indicates traffic is rejected with a prohibited
+	// error.
+	RouteTypeProhibit
+	// RouteTypeThrow indicates the lookup should continue on the next
+	// policy routing rule.
+	RouteTypeThrow
+	// RouteTypeNAT indicates a network address translation rule.
+	RouteTypeNAT
+	// RouteTypeXResolve indicates an external resolver handles the route.
+	RouteTypeXResolve
+)
+
+var routeTypeNames = [...]string{
+	RouteTypeUnspec:      "unspec",
+	RouteTypeUnicast:     "unicast",
+	RouteTypeLocal:       "local",
+	RouteTypeBroadcast:   "broadcast",
+	RouteTypeAnycast:     "anycast",
+	RouteTypeMulticast:   "multicast",
+	RouteTypeBlackhole:   "blackhole",
+	RouteTypeUnreachable: "unreachable",
+	RouteTypeProhibit:    "prohibit",
+	RouteTypeThrow:       "throw",
+	RouteTypeNAT:         "nat",
+	RouteTypeXResolve:    "xresolve",
+}
+
+func (t RouteType) String() string {
+	if int(t) < len(routeTypeNames) {
+		return routeTypeNames[t]
+	}
+	return "unknown"
+}
+
+// Forwards returns whether routes of this type forward traffic to a next
+// hop. Routes with an unspecified type are assumed to do so.
+func (t RouteType) Forwards() bool {
+	return t == RouteTypeUnspec || t == RouteTypeUnicast
+}
+
+// Rejects returns whether routes of this type drop or reject traffic.
+func (t RouteType) Rejects() bool {
+	return t == RouteTypeBlackhole || t == RouteTypeUnreachable || t == RouteTypeProhibit
+}
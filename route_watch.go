@@ -0,0 +1,191 @@
+package gateway
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"time"
+)
+
+// RouteEventType indicates how a route changed.
+type RouteEventType uint8
+
+const (
+	RouteAdded RouteEventType = iota + 1
+	RouteRemoved
+	// RouteChanged indicates a route was replaced by another one having the
+	// same destination, source, table, TOS and metric, but a different
+	// gateway, interface, or attributes, such as through `ip route replace`.
+	RouteChanged
+)
+
+var routeEventTypeNames = [...]string{
+	RouteAdded:   "added",
+	RouteRemoved: "removed",
+	RouteChanged: "changed",
+}
+
+func (t RouteEventType) String() string {
+	if t > 0 && int(t) < len(routeEventTypeNames) {
+		return routeEventTypeNames[t]
+	}
+	return "unknown"
+}
+
+// RouteEvent describes a change of the routing tables.
+type RouteEvent struct {
+	Type RouteEventType
+	// Route is the route which was added or removed. For changes, it is the
+	// new version of the route.
+	Route NetRoute
+	// Previous is the former version of changed routes.
+	Previous NetRoute
+	// Resync is set on events synthesized by comparing a fresh copy of the
+	// routing tables with the previous one, after notifications were lost.
+	Resync bool
+}
+
+// RouteWatcher delivers route events until its context is done, or it fails.
+type RouteWatcher struct {
+	events chan RouteEvent
+	err    error
+	// initial holds routes present when the watcher started, which events
+	// are relative to.
+	initial NetRouteList
+}
+
+func newRouteWatcher(initial NetRouteList) *RouteWatcher {
+	return &RouteWatcher{events: make(chan RouteEvent), initial: initial}
+}
+
+// Events returns the channel events are delivered on. It is closed once the
+// watcher stops.
+func (w *RouteWatcher) Events() <-chan RouteEvent {
+	return w.events
+}
+
+// Err returns the error which stopped the watcher, once Events is closed. It
+// is nil for watchers stopped by their context.
+func (w *RouteWatcher) Err() error {
+	return w.err
+}
+
+// send delivers events, returning false if ctx is done first.
+func (w *RouteWatcher) send(ctx context.Context, events []RouteEvent) bool {
+	for _, e := range events {
+		select {
+		case w.events <- e:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// routeKey identifies a route within the routing tables, the way the kernel
+// does: routes sharing a key are versions of one another, and replacing a
+// route swaps the one having the same key. Backends unable to report tables
+// list several defaults, one per interface, with neither tables nor metrics
+// telling them apart; the interface is part of the key of their routes.
+type routeKey struct {
+	kind        NetRouteKind
+	table       uint32
+	destination string
+	src         netip.Prefix
+	tos         uint8
+	metric      uint32
+	netif       string
+}
+
+func keyOf(r *NetRoute) routeKey {
+	k := routeKey{
+		kind:        r.Kind,
+		table:       r.Table,
+		destination: r.Destination,
+		src:         r.Src,
+		tos:         r.TOS,
+		metric:      r.Metric,
+	}
+	if r.Table == 0 {
+		k.netif = r.Netif
+	}
+	return k
+}
+
+// sameRoute returns whether a and b are equal, except for ExpiresAt, which
+// backends compute from the time routes are read.
+func sameRoute(a, b NetRoute) bool {
+	a.ExpiresAt, b.ExpiresAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+// routeState tracks the routes known to a watcher.
+type routeState map[routeKey]NetRoute
+
+func newRouteState(routes NetRouteList) routeState {
+	s := make(routeState, len(routes))
+	for _, r := range routes {
+		s[keyOf(&r)] = r
+	}
+	return s
+}
+
+// equal returns whether routes matches the known routes.
+func (s routeState) equal(routes NetRouteList) bool {
+	other := newRouteState(routes)
+	if len(other) != len(s) {
+		return false
+	}
+	for k, r := range other {
+		if prev, ok := s[k]; !ok || !sameRoute(prev, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// add records r, returning the event describing the change it represents,
+// if any.
+func (s routeState) add(r NetRoute) (RouteEvent, bool) {
+	k := keyOf(&r)
+	prev, ok := s[k]
+	s[k] = r
+	switch {
+	case !ok:
+		return RouteEvent{Type: RouteAdded, Route: r}, true
+	case !sameRoute(prev, r):
+		return RouteEvent{Type: RouteChanged, Route: r, Previous: prev}, true
+	}
+	return RouteEvent{}, false
+}
+
+// remove forgets r, returning the event describing its removal, if it was
+// known.
+func (s routeState) remove(r NetRoute) (RouteEvent, bool) {
+	k := keyOf(&r)
+	prev, ok := s[k]
+	if !ok {
+		return RouteEvent{}, false
+	}
+	delete(s, k)
+	return RouteEvent{Type: RouteRemoved, Route: prev}, true
+}
+
+// sync replaces the known routes with routes, returning events describing
+// the differences.
+func (s routeState) sync(routes NetRouteList) []RouteEvent {
+	var events []RouteEvent
+	current := newRouteState(routes)
+	for k, r := range s {
+		if _, ok := current[k]; !ok {
+			events = append(events, RouteEvent{Type: RouteRemoved, Route: r})
+			delete(s, k)
+		}
+	}
+	for _, r := range routes {
+		if e, ok := s.add(r); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"syscall"
+)
+
+// RTMGRP_* multicast groups, as used with the legacy bitmask of
+// sockaddr_nl.nl_groups.
+const (
+	rtmgrpIPv4Route = 0x40
+	rtmgrpIPv6Route = 0x400
+)
+
+// WatchRoutes returns a RouteWatcher delivering changes of the routing tables
+// of the current network namespace as they happen, through the
+// RTNLGRP_IPV4_ROUTE and RTNLGRP_IPV6_ROUTE rtnetlink groups. Routes present
+// when the watcher starts are not reported.
+//
+// When the kernel drops notifications because events aren't consumed fast
+// enough, routes are read again, and differences are delivered as events
+// flagged with Resync. The watcher stops once ctx is done.
+func WatchRoutes(ctx context.Context) (*RouteWatcher, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	sa := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: rtmgrpIPv4Route | rtmgrpIPv6Route}
+	if err = syscall.Bind(fd, sa); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	// Being non-blocking, the socket is handled by the runtime poller, which
+	// allows closing it to interrupt pending reads.
+	f := os.NewFile(uintptr(fd), "netlink")
+
+	// Routes are read once subscribed, so that no change is missed.
+	routes, err := netlinkRoutes()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// Without procfs, lookups can only be made from the current namespace.
+	ns, _ := os.Open("/proc/thread-self/ns/net")
+
+	w := newRouteWatcher(routes)
+	go w.watchNetlink(ctx, f, ns, newRouteState(routes))
+	return w, nil
+}
+
+// watchNetlink reads notifications from f until ctx is done. Links and routes
+// are looked up from within ns, the namespace WatchRoutes was called from,
+// which differs from the one of the goroutine when called through
+// Namespace.Do. ns is nil when unknown.
+func (w *RouteWatcher) watchNetlink(ctx context.Context, f *os.File, ns *os.File, state routeState) {
+	defer close(w.events)
+	defer f.Close()
+	stop := context.AfterFunc(ctx, func() { f.Close() })
+	defer stop()
+
+	inNamespace := func(fn func() error) error { return fn() }
+	if ns != nil {
+		defer ns.Close()
+		own, err := ns.Stat()
+		current, curErr := os.Stat("/proc/thread-self/ns/net")
+		if err == nil && curErr == nil && !os.SameFile(own, current) {
+			inNamespace = NamespaceFromFD(int(ns.Fd())).Do
+		}
+	}
+
+	rc, err := f.SyscallConn()
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	buf := make([]byte, 1<<16)
+	for {
+		var n int
+		var from syscall.Sockaddr
+		var recvErr error
+		err = rc.Read(func(fd uintptr) bool {
+			n, from, recvErr = syscall.Recvfrom(int(fd), buf, 0)
+			return recvErr != syscall.EAGAIN
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.err = err
+			return
+		}
+
+		var events []RouteEvent
+		switch {
+		case errors.Is(recvErr, syscall.ENOBUFS):
+			// Notifications were dropped; compare with a fresh copy.
+			var routes NetRouteList
+			err = inNamespace(func() (err error) {
+				routes, err = netlinkRoutes()
+				return err
+			})
+			if err != nil {
+				w.err = err
+				return
+			}
+			events = state.sync(routes)
+			for i := range events {
+				events[i].Resync = true
+			}
+		case recvErr != nil:
+			w.err = os.NewSyscallError("recvfrom", recvErr)
+			return
+		default:
+			if sa, ok := from.(*syscall.SockaddrNetlink); !ok || sa.Pid != 0 {
+				// Not sent by the kernel.
+				continue
+			}
+			err = inNamespace(func() (err error) {
+				events, err = netlinkRouteEvents(buf[:n], state)
+				return err
+			})
+			if err != nil {
+				w.err = err
+				return
+			}
+		}
+
+		if !w.send(ctx, events) {
+			return
+		}
+	}
+}
+
+// netlinkRouteEvents applies the route notifications contained in b to state,
+// returning the resulting events.
+func netlinkRouteEvents(b []byte, state routeState) ([]RouteEvent, error) {
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return nil, err
+	}
+	// Interfaces may have appeared since the last notification.
+	links, err := linkNames()
+	if err != nil {
+		return nil, err
+	}
+
+	type change struct {
+		route   NetRoute
+		deleted bool
+	}
+	var changes []change
+	usesObjects := false
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWROUTE && m.Header.Type != syscall.RTM_DELROUTE {
+			continue
+		}
+		if len(m.Data) >= syscall.SizeofRtMsg && binary.NativeEndian.Uint32(m.Data[8:12])&syscall.RTM_F_CLONED != 0 {
+			// Cached routes, such as PMTU exceptions, aren't part of the
+			// tables.
+			continue
+		}
+		if r, ok := parseNetlinkRoute(m.Data, links); ok {
+			changes = append(changes, change{route: r, deleted: m.Header.Type == syscall.RTM_DELROUTE})
+			usesObjects = usesObjects || r.NextHopID != 0
+		}
+	}
+
+	if usesObjects {
+		// Resolve next hops the same way as netlinkRoutes, so that resyncs don't
+		// report spurious changes.
+		if objects, err := netlinkNextHops(links); err == nil {
+			routes := make(NetRouteList, len(changes))
+			for i, c := range changes {
+				routes[i] = c.route
+			}
+			routes.resolveNextHops(objects)
+			for i := range changes {
+				changes[i].route = routes[i]
+			}
+		}
+	}
+
+	var events []RouteEvent
+	for _, c := range changes {
+		var e RouteEvent
+		var ok bool
+		if c.deleted {
+			e, ok = state.remove(c.route)
+		} else {
+			// Routes replaced through NLM_F_REPLACE share their key with
+			// the new version, which add reports as a change.
+			e, ok = state.add(c.route)
+		}
+		if ok {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchRoutes(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	ip := func(t *testing.T, stdin string, args ...string) {
+		t.Helper()
+		require.NoError(t, ns.Do(func() error {
+			cmd := exec.Command("ip", args...)
+			cmd.Stdin = strings.NewReader(stdin)
+			return cmd.Run()
+		}))
+	}
+	watch := func(t *testing.T) (*RouteWatcher, context.CancelFunc) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		var w *RouteWatcher
+		require.NoError(t, ns.Do(func() (err error) {
+			w, err = WatchRoutes(ctx)
+			return err
+		}))
+		return w, cancel
+	}
+	next := func(t *testing.T, w *RouteWatcher) RouteEvent {
+		t.Helper()
+		select {
+		case e, ok := <-w.Events():
+			require.True(t, ok, "watcher stopped: %v", w.Err())
+			return e
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no event received")
+		}
+		return RouteEvent{}
+	}
+
+	// d0 has no address, so that bringing it up adds no route.
+	runIP(t, ns, [][]string{
+		{"link", "add", "d0", "type", "veth", "peer", "name", "d1"},
+		{"link", "set", "d0", "addrgenmode", "none"},
+		{"link", "set", "d1", "addrgenmode", "none"},
+		{"link", "set", "d1", "up"},
+		{"link", "set", "d0", "up"},
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		w, cancel := watch(t)
+
+		ip(t, "", "route", "add", "10.20.0.0/16", "via", "10.9.0.1", "dev", "lo", "onlink")
+		e := next(t, w)
+		assert.Equal(t, RouteAdded, e.Type)
+		assert.Equal(t, "10.20.0.0/16", e.Route.Destination)
+		assert.Equal(t, "10.9.0.1", e.Route.Gateway)
+		assert.False(t, e.Resync)
+
+		ip(t, "", "route", "replace", "10.20.0.0/16", "via", "10.9.0.2", "dev", "lo", "onlink")
+		e = next(t, w)
+		assert.Equal(t, RouteChanged, e.Type)
+		assert.Equal(t, "10.9.0.2", e.Route.Gateway)
+		assert.Equal(t, "10.9.0.1", e.Previous.Gateway)
+
+		ip(t, "", "route", "replace", "10.20.0.0/16", "via", "10.9.0.2", "dev", "d0", "onlink")
+		e = next(t, w)
+		assert.Equal(t, RouteChanged, e.Type)
+		assert.Equal(t, "d0", e.Route.Netif)
+		assert.Equal(t, "lo", e.Previous.Netif)
+
+		ip(t, "", "route", "del", "10.20.0.0/16")
+		e = next(t, w)
+		assert.Equal(t, RouteRemoved, e.Type)
+		assert.Equal(t, "d0", e.Route.Netif)
+
+		ip(t, "", "route", "del", "default")
+		e = next(t, w)
+		assert.Equal(t, RouteRemoved, e.Type)
+		assert.Equal(t, "default", e.Route.Destination)
+
+		cancel()
+		for range w.Events() {
+		}
+		assert.NoError(t, w.Err())
+	})
+
+	t.Run("Resync", func(t *testing.T) {
+		w, _ := watch(t)
+
+		// Notifications overflow the socket buffer while no event is read.
+		const count = 5000
+		var batch strings.Builder
+		for i := 0; i < count; i++ {
+			fmt.Fprintf(&batch, "route add 10.30.%d.%d/32 dev lo\n", i/256, i%256)
+		}
+		ip(t, batch.String(), "-batch", "-")
+
+		added := map[string]bool{}
+		resynced := false
+		for len(added) < count {
+			e := next(t, w)
+			if e.Type == RouteAdded && strings.HasPrefix(e.Route.Destination, "10.30.") {
+				added[e.Route.Destination] = true
+			}
+			resynced = resynced || e.Resync
+		}
+		assert.True(t, resynced)
+	})
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package gateway
+
+import "context"
+
+// WatchRoutes returns a RouteWatcher delivering changes of the routing
+// tables. Lacking a notification mechanism on this platform, routes are
+// polled using the default options of PollRoutes.
+func WatchRoutes(ctx context.Context) (*RouteWatcher, error) {
+	return PollRoutes(ctx)
+}
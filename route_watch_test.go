@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteState(t *testing.T) {
+	gw1 := NetRoute{Kind: NetRouteKindV4, Destination: "default", Gateway: "10.0.0.1", Netif: "eth0", Table: routeTableMain}
+	gw2 := gw1
+	gw2.Gateway = "10.0.0.2"
+	lan := NetRoute{Kind: NetRouteKindV4, Destination: "10.0.0.0/24", Netif: "eth0", Table: routeTableMain}
+	wifi := NetRoute{Kind: NetRouteKindV4, Destination: "default", Gateway: "192.168.1.1", Netif: "wlan0", Table: routeTableMain, Metric: 600}
+
+	state := newRouteState(NetRouteList{gw1, lan})
+
+	_, ok := state.add(lan)
+	assert.False(t, ok)
+	expiring := lan
+	expiring.ExpiresAt = time.Now()
+	_, ok = state.add(expiring)
+	assert.False(t, ok, "expiry alone is not a change")
+
+	e, ok := state.add(gw2)
+	assert.True(t, ok)
+	assert.Equal(t, RouteEvent{Type: RouteChanged, Route: gw2, Previous: gw1}, e)
+
+	e, ok = state.remove(lan)
+	assert.True(t, ok)
+	assert.Equal(t, RouteRemoved, e.Type)
+	_, ok = state.remove(lan)
+	assert.False(t, ok)
+
+	events := state.sync(NetRouteList{gw1, wifi})
+	assert.ElementsMatch(t, []RouteEvent{
+		{Type: RouteChanged, Route: gw1, Previous: gw2},
+		{Type: RouteAdded, Route: wifi},
+	}, events)
+	assert.Empty(t, state.sync(NetRouteList{gw1, wifi}))
+	assert.Equal(t, []RouteEvent{{Type: RouteRemoved, Route: wifi}}, state.sync(NetRouteList{gw1}))
+
+	// Replacing a route onto another interface changes it, as the kernel
+	// keeps a single route per destination, table, TOS and metric.
+	moved := gw1
+	moved.Netif = "eth1"
+	e, ok = state.add(moved)
+	assert.True(t, ok)
+	assert.Equal(t, RouteEvent{Type: RouteChanged, Route: moved, Previous: gw1}, e)
+	tos := moved
+	tos.TOS = 0x10
+	e, ok = state.add(tos)
+	assert.True(t, ok)
+	assert.Equal(t, RouteAdded, e.Type)
+
+	t.Run("Unknown tables", func(t *testing.T) {
+		// netstat lists one default per interface.
+		en0 := NetRoute{Kind: NetRouteKindV4, Destination: "default", Gateway: "10.0.0.1", Netif: "en0"}
+		en1 := NetRoute{Kind: NetRouteKindV4, Destination: "default", Gateway: "10.0.0.1", Netif: "en1"}
+		state := newRouteState(NetRouteList{en0})
+		e, ok := state.add(en1)
+		assert.True(t, ok)
+		assert.Equal(t, RouteAdded, e.Type)
+		assert.Len(t, state, 2)
+	})
+}
@@ -0,0 +1,228 @@
+package gateway
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// RuleAction indicates what happens once a policy routing rule matches.
+// Values mirror the kernel's FR_ACT_* constants.
+type RuleAction uint8
+
+const (
+	RuleActionUnspec      RuleAction = 0
+	RuleActionToTable     RuleAction = 1
+	RuleActionGoto        RuleAction = 2
+	RuleActionNop         RuleAction = 3
+	RuleActionBlackhole   RuleAction = 6
+	RuleActionUnreachable RuleAction = 7
+	RuleActionProhibit    RuleAction = 8
+)
+
+func (a RuleAction) String() string {
+	switch a {
+	case RuleActionToTable:
+		return "lookup"
+	case RuleActionGoto:
+		return "goto"
+	case RuleActionNop:
+		return "nop"
+	case RuleActionBlackhole:
+		return "blackhole"
+	case RuleActionUnreachable:
+		return "unreachable"
+	case RuleActionProhibit:
+		return "prohibit"
+	default:
+		return "unspec"
+	}
+}
+
+// UIDRange is an inclusive range of user IDs matched by a Rule.
+type UIDRange struct {
+	Start uint32
+	End   uint32
+}
+
+// Rule represents an entry of the policy routing database, as listed by
+// `ip rule`. Selectors left at their zero value match any flow.
+type Rule struct {
+	Kind     NetRouteKind
+	Priority uint32
+	Action   RuleAction
+	// Table is the routing table consulted by RuleActionToTable.
+	Table uint32
+	// Goto is the priority of the rule to jump to when Action is
+	// RuleActionGoto.
+	Goto uint32
+	// Invert negates the result of the selectors ("not" in `ip rule`).
+	Invert bool
+
+	Src      netip.Prefix
+	Dst      netip.Prefix
+	Mark     uint32
+	Mask     uint32
+	UIDRange *UIDRange
+	IIf      string
+	OIf      string
+
+	// SuppressPrefixLength rejects lookup results whose prefix length is
+	// less than or equal to its value. nil when unset.
+	SuppressPrefixLength *int
+}
+
+// Flow describes the traffic to be routed by LookupRoute.
+type Flow struct {
+	// Src is the source address of the traffic. It may be left unset for
+	// locally generated traffic.
+	Src netip.Addr
+	Dst netip.Addr
+	// Mark is the firewall mark (fwmark) of the traffic.
+	Mark uint32
+	// UID is the user ID of the socket owner.
+	UID uint32
+	// IIf is the input interface. An empty value indicates locally
+	// generated traffic, which the kernel treats as arriving from "lo".
+	IIf string
+	// OIf restricts the lookup to routes using the given output interface.
+	OIf string
+}
+
+// RouteLookup is the result of LookupRoute.
+type RouteLookup struct {
+	// Rule is the policy routing rule which selected the table.
+	Rule Rule
+	// Route is the route selected from the table.
+	Route NetRoute
+}
+
+func (r *Rule) matches(f *Flow) bool {
+	return r.selectorsMatch(f) != r.Invert
+}
+
+func (r *Rule) selectorsMatch(f *Flow) bool {
+	if r.Src.IsValid() && !r.Src.Contains(flowAddr(f.Src, r.Kind)) {
+		return false
+	}
+	if r.Dst.IsValid() && !r.Dst.Contains(flowAddr(f.Dst, r.Kind)) {
+		return false
+	}
+	iif := f.IIf
+	if iif == "" {
+		iif = "lo"
+	}
+	if r.IIf != "" && r.IIf != iif {
+		return false
+	}
+	if r.OIf != "" && r.OIf != f.OIf {
+		return false
+	}
+	if (f.Mark^r.Mark)&r.Mask != 0 {
+		return false
+	}
+	if r.UIDRange != nil && (f.UID < r.UIDRange.Start || f.UID > r.UIDRange.End) {
+		return false
+	}
+	return true
+}
+
+// flowAddr returns addr unmapped, or the unspecified address of the given
+// kind when addr is not set.
+func flowAddr(addr netip.Addr, kind NetRouteKind) netip.Addr {
+	if addr.IsValid() {
+		return addr.Unmap()
+	}
+	if kind == NetRouteKindV4 {
+		return netip.IPv4Unspecified()
+	}
+	return netip.IPv6Unspecified()
+}
+
+// lookupTable returns the most specific route in the given table matching
+// the flow's destination, preferring the lowest metric among equally
+// specific routes.
+func (n NetRouteList) lookupTable(kind NetRouteKind, table uint32, f *Flow) (NetRoute, bool) {
+	dst := f.Dst.Unmap()
+	var best *NetRoute
+	for i := range n {
+		r := &n[i]
+		if r.Kind != kind || r.Table != table || !r.Dst.IsValid() || !r.Dst.Contains(dst) {
+			continue
+		}
+		if f.OIf != "" && r.Netif != f.OIf {
+			continue
+		}
+		// Source-specific routes only match flows from within their source
+		// prefix, and are preferred over others with the same destination.
+		if r.Src.IsValid() && !r.Src.Contains(f.Src.Unmap()) {
+			continue
+		}
+		if best == nil ||
+			r.Dst.Bits() > best.Dst.Bits() ||
+			(r.Dst.Bits() == best.Dst.Bits() && r.Src.Bits() > best.Src.Bits()) ||
+			(r.Dst.Bits() == best.Dst.Bits() && r.Src.Bits() == best.Src.Bits() && r.Metric < best.Metric) {
+			best = r
+		}
+	}
+	if best == nil {
+		return NetRoute{}, false
+	}
+	return *best, true
+}
+
+// lookupRoute evaluates rules in priority order against the flow, mimicking
+// the kernel's fib_rules_lookup, and returns the route selected by the first
+// rule yielding a result.
+func lookupRoute(rules []Rule, routes NetRouteList, f Flow) (*RouteLookup, error) {
+	kind := NetRouteKindV6
+	if f.Dst.Unmap().Is4() {
+		kind = NetRouteKindV4
+	}
+
+	var candidates []Rule
+	for _, r := range rules {
+		if r.Kind == kind {
+			candidates = append(candidates, r)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Priority < candidates[j].Priority
+	})
+
+	for i := 0; i < len(candidates); i++ {
+		r := &candidates[i]
+		if !r.matches(&f) {
+			continue
+		}
+
+		switch r.Action {
+		case RuleActionGoto:
+			// The target must be a later rule with the exact priority;
+			// unresolved targets are skipped by the kernel.
+			for j := i + 1; j < len(candidates); j++ {
+				if candidates[j].Priority == r.Goto {
+					i = j - 1
+					break
+				}
+			}
+		case RuleActionBlackhole, RuleActionUnreachable, RuleActionProhibit:
+			return nil, &ErrNoRoute{dst: f.Dst, rule: r}
+		case RuleActionToTable:
+			route, ok := routes.lookupTable(kind, r.Table, &f)
+			if !ok || route.Type == RouteTypeThrow {
+				continue
+			}
+			// As with `ip route get`, a rejecting route fails the lookup,
+			// regardless of suppressors.
+			if route.Type.Rejects() {
+				return nil, &ErrNoRoute{dst: f.Dst, rule: r, route: &route}
+			}
+			if r.SuppressPrefixLength != nil && route.Dst.Bits() <= *r.SuppressPrefixLength {
+				continue
+			}
+			return &RouteLookup{Rule: *r, Route: route}, nil
+		}
+	}
+
+	return nil, &ErrNoRoute{dst: f.Dst}
+}
@@ -0,0 +1,127 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"syscall"
+)
+
+/* Keep this in sync with /usr/src/linux/include/uapi/linux/fib_rules.h */
+
+const (
+	fraDst             = 1
+	fraSrc             = 2
+	fraIIfName         = 3
+	fraGoto            = 4
+	fraPriority        = 6
+	fraFwMark          = 10
+	fraSuppressPrefLen = 14
+	fraTable           = 15
+	fraFwMask          = 16
+	fraOIfName         = 17
+	fraUIDRange        = 20
+
+	fibRuleInvert = 0x00000002
+)
+
+// ListRules returns the policy routing rules of both IPv4 and IPv6 families,
+// as listed by `ip rule` and `ip -6 rule`.
+func ListRules() ([]Rule, error) {
+	msgs, err := netlinkDump(syscall.RTM_GETRULE, make([]byte, syscall.SizeofRtMsg))
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWRULE {
+			continue
+		}
+		if r, ok := parseNetlinkRule(m.Data); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// LookupRoute determines the route the kernel would use for the provided
+// flow, evaluating policy routing rules in priority order to select the
+// routing table, and then the most specific route within it. The result is
+// analogous to `ip route get`.
+func LookupRoute(flow Flow) (*RouteLookup, error) {
+	rules, err := ListRules()
+	if err != nil {
+		return nil, err
+	}
+	routes, err := netlinkRoutes()
+	if err != nil {
+		return nil, err
+	}
+	return lookupRoute(rules, routes, flow)
+}
+
+/* fib_rule_hdr:
++--------+---------+---------+-----+-------+------+------+--------+-------+
+| family | dst_len | src_len | tos | table | res1 | res2 | action | flags |
++--------+---------+---------+-----+-------+------+------+--------+-------+
+    u8       u8        u8      u8     u8     u8     u8      u8      u32
+*/
+
+func parseNetlinkRule(b []byte) (Rule, bool) {
+	if len(b) < 12 {
+		return Rule{}, false
+	}
+
+	var kind NetRouteKind
+	switch b[0] {
+	case syscall.AF_INET:
+		kind = NetRouteKindV4
+	case syscall.AF_INET6:
+		kind = NetRouteKindV6
+	default:
+		return Rule{}, false
+	}
+
+	attrs := parseNetlinkAttrs(b[12:])
+	rule := Rule{
+		Kind:   kind,
+		Action: RuleAction(b[7]),
+		Table:  uint32(b[4]),
+		Invert: binary.NativeEndian.Uint32(b[8:12])&fibRuleInvert != 0,
+		IIf:    attrs.string(fraIIfName),
+		OIf:    attrs.string(fraOIfName),
+	}
+	if v, ok := attrs.uint32(fraTable); ok {
+		rule.Table = v
+	}
+	if v, ok := attrs.uint32(fraPriority); ok {
+		rule.Priority = v
+	}
+	if v, ok := attrs.uint32(fraGoto); ok {
+		rule.Goto = v
+	}
+	if v, ok := netip.AddrFromSlice(attrs.get(fraSrc)); ok {
+		rule.Src = netip.PrefixFrom(v, int(b[2]))
+	}
+	if v, ok := netip.AddrFromSlice(attrs.get(fraDst)); ok {
+		rule.Dst = netip.PrefixFrom(v, int(b[1]))
+	}
+	if v, ok := attrs.uint32(fraFwMark); ok {
+		rule.Mark, rule.Mask = v, 0xFFFFFFFF
+	}
+	if v, ok := attrs.uint32(fraFwMask); ok {
+		rule.Mask = v
+	}
+	if v := attrs.get(fraUIDRange); len(v) >= 8 {
+		rule.UIDRange = &UIDRange{
+			Start: binary.NativeEndian.Uint32(v[0:4]),
+			End:   binary.NativeEndian.Uint32(v[4:8]),
+		}
+	}
+	if v, ok := attrs.uint32(fraSuppressPrefLen); ok && int32(v) >= 0 {
+		l := int(int32(v))
+		rule.SuppressPrefixLength = &l
+	}
+
+	return rule, true
+}
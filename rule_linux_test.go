@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRules(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"rule", "add", "pref", "100", "from", "10.1.0.0/24", "table", "100"},
+		{"rule", "add", "pref", "200", "fwmark", "0x10/0xf0", "lookup", "200"},
+		{"rule", "add", "pref", "300", "not", "to", "10.0.0.0/8", "uidrange", "1000-1999", "prohibit"},
+		{"rule", "add", "pref", "400", "iif", "eth9", "oif", "lo", "goto", "500"},
+		{"rule", "add", "pref", "500", "lookup", "main", "suppress_prefixlength", "0"},
+		{"-6", "rule", "add", "pref", "600", "from", "2001:db8::/32", "table", "300"},
+		{"route", "add", "default", "via", "10.9.0.2", "dev", "lo", "onlink", "table", "100"},
+		{"route", "add", "unreachable", "10.200.0.0/16"},
+	})
+
+	var rules []Rule
+	var viaSrc *RouteLookup
+	var unreachableErr error
+	require.NoError(t, ns.Do(func() (err error) {
+		if rules, err = ListRules(); err != nil {
+			return err
+		}
+		if viaSrc, err = LookupRoute(Flow{Src: netip.MustParseAddr("10.1.0.5"), Dst: netip.MustParseAddr("1.1.1.1")}); err != nil {
+			return err
+		}
+		// Rule 300 doesn't apply to the UID, leaving the main table.
+		_, unreachableErr = LookupRoute(Flow{Dst: netip.MustParseAddr("10.200.0.1"), UID: 1500})
+		return nil
+	}))
+
+	byPriority := map[uint32]Rule{}
+	for _, r := range rules {
+		if r.Priority >= 100 && r.Priority <= 600 {
+			byPriority[r.Priority] = r
+		}
+	}
+	suppress := 0
+	assert.Equal(t, map[uint32]Rule{
+		100: {Kind: NetRouteKindV4, Priority: 100, Action: RuleActionToTable, Table: 100, Src: netip.MustParsePrefix("10.1.0.0/24")},
+		200: {Kind: NetRouteKindV4, Priority: 200, Action: RuleActionToTable, Table: 200, Mark: 0x10, Mask: 0xf0},
+		300: {Kind: NetRouteKindV4, Priority: 300, Action: RuleActionProhibit, Invert: true, Dst: netip.MustParsePrefix("10.0.0.0/8"), UIDRange: &UIDRange{Start: 1000, End: 1999}},
+		400: {Kind: NetRouteKindV4, Priority: 400, Action: RuleActionGoto, Goto: 500, IIf: "eth9", OIf: "lo"},
+		500: {Kind: NetRouteKindV4, Priority: 500, Action: RuleActionToTable, Table: routeTableMain, SuppressPrefixLength: &suppress},
+		600: {Kind: NetRouteKindV6, Priority: 600, Action: RuleActionToTable, Table: 300, Src: netip.MustParsePrefix("2001:db8::/32")},
+	}, byPriority)
+
+	assert.Equal(t, uint32(100), viaSrc.Rule.Priority)
+	assert.Equal(t, "10.9.0.2", viaSrc.Route.Gateway)
+	var noRoute *ErrNoRoute
+	require.ErrorAs(t, unreachableErr, &noRoute)
+	require.NotNil(t, noRoute.route)
+	assert.Equal(t, RouteTypeUnreachable, noRoute.route.Type)
+}
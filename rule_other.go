@@ -0,0 +1,15 @@
+//go:build !linux
+
+package gateway
+
+// ListRules returns the policy routing rules of both IPv4 and IPv6 families.
+// Policy routing is only supported on Linux.
+func ListRules() ([]Rule, error) {
+	return nil, &ErrNotImplemented{}
+}
+
+// LookupRoute determines the route the kernel would use for the provided
+// flow. Policy routing is only supported on Linux.
+func LookupRoute(Flow) (*RouteLookup, error) {
+	return nil, &ErrNotImplemented{}
+}
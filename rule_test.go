@@ -0,0 +1,134 @@
+package gateway
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupRoute(t *testing.T) {
+	suppress := 0
+	rules := []Rule{
+		{Kind: NetRouteKindV4, Priority: 0, Action: RuleActionToTable, Table: 255},
+		{Kind: NetRouteKindV4, Priority: 100, Action: RuleActionToTable, Table: 100, Src: netip.MustParsePrefix("10.1.0.0/24")},
+		{Kind: NetRouteKindV4, Priority: 200, Action: RuleActionToTable, Table: 200, Mark: 0x10, Mask: 0xF0},
+		{Kind: NetRouteKindV4, Priority: 300, Action: RuleActionToTable, Table: 254, SuppressPrefixLength: &suppress},
+		{Kind: NetRouteKindV4, Priority: 400, Action: RuleActionGoto, Goto: 600, UIDRange: &UIDRange{Start: 1000, End: 1999}},
+		{Kind: NetRouteKindV4, Priority: 500, Action: RuleActionProhibit, UIDRange: &UIDRange{Start: 1000, End: 1999}},
+		{Kind: NetRouteKindV4, Priority: 600, Action: RuleActionBlackhole, IIf: "eth9"},
+		{Kind: NetRouteKindV4, Priority: 32766, Action: RuleActionToTable, Table: 254},
+	}
+	routes := NetRouteList{
+		{Kind: NetRouteKindV4, Table: 254, Dst: netip.MustParsePrefix("0.0.0.0/0"), Gateway: "10.0.0.1", Netif: "eth0", Metric: 100},
+		{Kind: NetRouteKindV4, Table: 254, Dst: netip.MustParsePrefix("0.0.0.0/0"), Gateway: "10.0.0.254", Netif: "eth0", Metric: 50},
+		{Kind: NetRouteKindV4, Table: 254, Dst: netip.MustParsePrefix("10.0.0.0/24"), Netif: "eth0"},
+		{Kind: NetRouteKindV4, Table: 100, Dst: netip.MustParsePrefix("0.0.0.0/0"), Gateway: "10.1.0.1", Netif: "eth1"},
+		{Kind: NetRouteKindV4, Table: 200, Dst: netip.MustParsePrefix("0.0.0.0/0"), Gateway: "10.2.0.1", Netif: "eth2"},
+		{Kind: NetRouteKindV4, Table: 255, Dst: netip.MustParsePrefix("10.0.0.2/32"), Netif: "eth0"},
+	}
+
+	lookup := func(t *testing.T, f Flow) (*RouteLookup, error) {
+		t.Helper()
+		return lookupRoute(rules, routes, f)
+	}
+
+	t.Run("Main table", func(t *testing.T) {
+		r, err := lookup(t, Flow{Dst: netip.MustParseAddr("1.1.1.1")})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(32766), r.Rule.Priority)
+		assert.Equal(t, "10.0.0.254", r.Route.Gateway)
+	})
+
+	t.Run("Suppressed prefix length", func(t *testing.T) {
+		r, err := lookup(t, Flow{Dst: netip.MustParseAddr("10.0.0.9")})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(300), r.Rule.Priority)
+		assert.Equal(t, "10.0.0.0/24", r.Route.Dst.String())
+	})
+
+	t.Run("Local table", func(t *testing.T) {
+		r, err := lookup(t, Flow{Dst: netip.MustParseAddr("10.0.0.2")})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(255), r.Route.Table)
+	})
+
+	t.Run("Source selector", func(t *testing.T) {
+		r, err := lookup(t, Flow{Src: netip.MustParseAddr("10.1.0.2"), Dst: netip.MustParseAddr("1.1.1.1")})
+		require.NoError(t, err)
+		assert.Equal(t, "10.1.0.1", r.Route.Gateway)
+	})
+
+	t.Run("Mark selector", func(t *testing.T) {
+		r, err := lookup(t, Flow{Mark: 0x1f, Dst: netip.MustParseAddr("1.1.1.1")})
+		require.NoError(t, err)
+		assert.Equal(t, "10.2.0.1", r.Route.Gateway)
+	})
+
+	t.Run("Goto skips rules", func(t *testing.T) {
+		r, err := lookup(t, Flow{UID: 1500, Dst: netip.MustParseAddr("1.1.1.1")})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(32766), r.Rule.Priority)
+	})
+
+	t.Run("Rejecting rule", func(t *testing.T) {
+		_, err := lookup(t, Flow{IIf: "eth9", UID: 1500, Dst: netip.MustParseAddr("1.1.1.1")})
+		var noRoute *ErrNoRoute
+		require.ErrorAs(t, err, &noRoute)
+		assert.Equal(t, RuleActionBlackhole, noRoute.rule.Action)
+	})
+
+	t.Run("Rejecting route", func(t *testing.T) {
+		rejecting := append(NetRouteList{
+			{Kind: NetRouteKindV4, Type: RouteTypeUnreachable, Table: 254, Dst: netip.MustParsePrefix("192.0.2.0/24")},
+		}, routes...)
+		_, err := lookupRoute(rules, rejecting, Flow{Dst: netip.MustParseAddr("192.0.2.1")})
+		var noRoute *ErrNoRoute
+		require.ErrorAs(t, err, &noRoute)
+		assert.Equal(t, RouteTypeUnreachable, noRoute.route.Type)
+		assert.EqualError(t, err, "no route to 192.0.2.1: unreachable route 192.0.2.0/24 in table 254")
+	})
+
+	t.Run("Inverted selector", func(t *testing.T) {
+		inverted := []Rule{
+			{Kind: NetRouteKindV4, Priority: 10, Action: RuleActionUnreachable, Invert: true, Dst: netip.MustParsePrefix("10.0.0.0/8")},
+			{Kind: NetRouteKindV4, Priority: 20, Action: RuleActionToTable, Table: 254},
+		}
+		_, err := lookupRoute(inverted, routes, Flow{Dst: netip.MustParseAddr("1.1.1.1")})
+		require.Error(t, err)
+		r, err := lookupRoute(inverted, routes, Flow{Dst: netip.MustParseAddr("10.0.0.5")})
+		require.NoError(t, err)
+		assert.Equal(t, "eth0", r.Route.Netif)
+	})
+
+	t.Run("No route", func(t *testing.T) {
+		_, err := lookup(t, Flow{Dst: netip.MustParseAddr("2001:db8::1")})
+		var noRoute *ErrNoRoute
+		require.ErrorAs(t, err, &noRoute)
+	})
+}
+
+func TestLookupRouteSourceSpecific(t *testing.T) {
+	rules := []Rule{{Kind: NetRouteKindV6, Priority: 32766, Action: RuleActionToTable, Table: 254}}
+	routes := NetRouteList{
+		{Kind: NetRouteKindV6, Table: 254, Dst: netip.MustParsePrefix("::/0"), Gateway: "fe80::3", Netif: "eth2", Metric: 1},
+		{Kind: NetRouteKindV6, Table: 254, Dst: netip.MustParsePrefix("::/0"), Src: netip.MustParsePrefix("2001:db8:1::/48"), Gateway: "fe80::1", Netif: "eth0", Metric: 1024},
+		{Kind: NetRouteKindV6, Table: 254, Dst: netip.MustParsePrefix("::/0"), Src: netip.MustParsePrefix("2001:db8:2::/48"), Gateway: "fe80::2", Netif: "eth1", Metric: 512},
+	}
+	dst := netip.MustParseAddr("2001:db8:ffff::1")
+
+	for src, gateway := range map[string]string{
+		"2001:db8:1:1::10": "fe80::1",
+		"2001:db8:2:1::10": "fe80::2",
+		"2001:db8:3:1::10": "fe80::3",
+	} {
+		r, err := lookupRoute(rules, routes, Flow{Src: netip.MustParseAddr(src), Dst: dst})
+		require.NoError(t, err)
+		assert.Equal(t, gateway, r.Route.Gateway, src)
+	}
+
+	r, err := lookupRoute(rules, routes, Flow{Dst: dst})
+	require.NoError(t, err)
+	assert.Equal(t, "fe80::3", r.Route.Gateway)
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import "net/netip"
+
+// VRF represents a Virtual Routing and Forwarding (l3mdev) device, which
+// binds its member interfaces to a dedicated routing table.
+type VRF struct {
+	Name  string
+	Index int
+	Table uint32
+	// Members lists names of interfaces enslaved to the VRF.
+	Members []string
+}
+
+// ListVRFs returns all VRF devices and their member interfaces.
+func ListVRFs() ([]VRF, error) {
+	return getVRFs()
+}
+
+func findVRF(name string) (*VRF, error) {
+	vrfs, err := getVRFs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range vrfs {
+		if vrfs[i].Name == name {
+			return &vrfs[i], nil
+		}
+	}
+	return nil, &ErrVRFNotFound{name: name}
+}
+
+// vrfOptions prepends an InTable option targeting the named VRF's table to
+// opts.
+func vrfOptions(name string, opts []DefaultsOption) ([]DefaultsOption, error) {
+	vrf, err := findVRF(name)
+	if err != nil {
+		return nil, err
+	}
+	return append([]DefaultsOption{InTable(vrf.Table)}, opts...), nil
+}
+
+// FindDefaultRoutesInVRF returns all default routes of the named VRF.
+func FindDefaultRoutesInVRF(name string, opts ...DefaultsOption) (NetRouteList, error) {
+	opts, err := vrfOptions(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return FindDefaultRoutes(opts...)
+}
+
+// FindDefaultGatewaysInVRF returns addresses of all gateways used by default
+// routes of the named VRF, both IPv4 and IPv6.
+func FindDefaultGatewaysInVRF(name string, opts ...DefaultsOption) ([]netip.Addr, error) {
+	opts, err := vrfOptions(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return FindDefaultGateways(opts...)
+}
+
+// FindDefaultInterfacesInVRF returns names of interfaces used by default
+// routes of the named VRF.
+func FindDefaultInterfacesInVRF(name string, opts ...DefaultsOption) ([]string, error) {
+	opts, err := vrfOptions(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return FindDefaultInterfaces(opts...)
+}
+
+var getVRFs func() ([]VRF, error) = nil
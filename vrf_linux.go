@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+/* Keep this in sync with /usr/src/linux/include/uapi/linux/if_link.h */
+
+const (
+	iflaInfoKind = 1
+	iflaInfoData = 2
+	iflaVRFTable = 1
+)
+
+func init() {
+	getVRFs = netlinkVRFs
+}
+
+// netlinkVRFs lists links through rtnetlink, returning VRF devices along with
+// interfaces enslaved to them.
+func netlinkVRFs() ([]VRF, error) {
+	msgs, err := netlinkDump(syscall.RTM_GETLINK, make([]byte, syscall.SizeofIfInfomsg))
+	if err != nil {
+		return nil, err
+	}
+	return parseNetlinkVRFs(msgs), nil
+}
+
+// parseNetlinkVRFs finds VRF devices and their members in RTM_NEWLINK
+// messages.
+func parseNetlinkVRFs(msgs []syscall.NetlinkMessage) []VRF {
+	var vrfs []VRF
+	masters := map[int][]string{}
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWLINK || len(m.Data) < syscall.SizeofIfInfomsg {
+			continue
+		}
+		index := int(int32(binary.NativeEndian.Uint32(m.Data[4:8])))
+		attrs := parseNetlinkAttrs(m.Data[syscall.SizeofIfInfomsg:])
+		name := attrs.string(syscall.IFLA_IFNAME)
+		if master, ok := attrs.uint32(syscall.IFLA_MASTER); ok {
+			masters[int(master)] = append(masters[int(master)], name)
+		}
+
+		info := parseNetlinkAttrs(attrs.get(syscall.IFLA_LINKINFO))
+		if info.string(iflaInfoKind) != "vrf" {
+			continue
+		}
+		table, _ := parseNetlinkAttrs(info.get(iflaInfoData)).uint32(iflaVRFTable)
+		vrfs = append(vrfs, VRF{Name: name, Index: index, Table: table})
+	}
+
+	for i := range vrfs {
+		vrfs[i].Members = masters[vrfs[i].Index]
+	}
+	return vrfs
+}
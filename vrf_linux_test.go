@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetlinkVRFs(t *testing.T) {
+	u32 := func(v uint32) []byte { return binary.NativeEndian.AppendUint32(nil, v) }
+	link := func(index int32, attrs ...[]byte) syscall.NetlinkMessage {
+		b := make([]byte, syscall.SizeofIfInfomsg)
+		binary.NativeEndian.PutUint32(b[4:8], uint32(index))
+		for _, a := range attrs {
+			b = append(b, a...)
+		}
+		return syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK}, Data: b}
+	}
+	linkInfo := func(kind string, data []byte) []byte {
+		info := testNetlinkAttr(iflaInfoKind, append([]byte(kind), 0))
+		if data != nil {
+			info = append(info, testNetlinkAttr(iflaInfoData, data)...)
+		}
+		return testNetlinkAttr(syscall.IFLA_LINKINFO, info)
+	}
+	name := func(v string) []byte { return testNetlinkAttr(syscall.IFLA_IFNAME, append([]byte(v), 0)) }
+
+	vrfs := parseNetlinkVRFs([]syscall.NetlinkMessage{
+		link(1, name("lo")),
+		link(2, name("eth0"), testNetlinkAttr(syscall.IFLA_MASTER, u32(10))),
+		link(3, name("eth1"), testNetlinkAttr(syscall.IFLA_MASTER, u32(11))),
+		link(10, name("mgmt"), linkInfo("vrf", testNetlinkAttr(iflaVRFTable, u32(100)))),
+		link(11, name("br0"), linkInfo("bridge", nil)),
+		link(4, name("eth2"), testNetlinkAttr(syscall.IFLA_MASTER, u32(10))),
+		{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK}, Data: []byte{0, 0}},
+	})
+	assert.Equal(t, []VRF{
+		{Name: "mgmt", Index: 10, Table: 100, Members: []string{"eth0", "eth2"}},
+	}, vrfs)
+}
+
+func TestListVRFs(t *testing.T) {
+	ns := NamespaceFromPID(startNamespace(t))
+	runIP(t, ns, [][]string{
+		{"link", "add", "br0", "type", "bridge"},
+		{"link", "add", "veth0", "type", "veth", "peer", "name", "veth1"},
+		{"link", "set", "veth1", "master", "br0"},
+	})
+
+	// Devices enslaved to other kinds of masters aren't reported.
+	var vrfs []VRF
+	require.NoError(t, ns.Do(func() (err error) {
+		vrfs, err = ListVRFs()
+		return err
+	}))
+	assert.Empty(t, vrfs)
+
+	runIP(t, ns, [][]string{
+		{"link", "add", "mgmt", "type", "vrf", "table", "100"},
+		{"link", "set", "veth0", "master", "mgmt"},
+	})
+	require.NoError(t, ns.Do(func() (err error) {
+		vrfs, err = ListVRFs()
+		return err
+	}))
+	require.Len(t, vrfs, 1)
+	assert.Equal(t, "mgmt", vrfs[0].Name)
+	assert.Equal(t, uint32(100), vrfs[0].Table)
+	assert.Equal(t, []string{"veth0"}, vrfs[0].Members)
+}
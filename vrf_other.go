@@ -0,0 +1,9 @@
+//go:build !linux
+
+package gateway
+
+func init() {
+	getVRFs = func() ([]VRF, error) {
+		return nil, &ErrNotImplemented{}
+	}
+}
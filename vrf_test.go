@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setVRFs(t *testing.T, vrfs []VRF) {
+	t.Helper()
+	prevVRFs := getVRFs
+	getVRFs = func() ([]VRF, error) {
+		return vrfs, nil
+	}
+	t.Cleanup(func() {
+		getVRFs = prevVRFs
+	})
+}
+
+func TestVRF(t *testing.T) {
+	setVRFs(t, []VRF{
+		{Name: "mgmt", Index: 10, Table: 10, Members: []string{"eth1"}},
+		{Name: "blue", Index: 11, Table: 20, Members: []string{"eth2", "eth3"}},
+	})
+	setRoutes(t, NetRouteList{
+		{Kind: NetRouteKindV4, Table: 254, Destination: "default", Flags: "UG", Gateway: "10.0.0.1", Netif: "eth0"},
+		{Kind: NetRouteKindV4, Table: 10, Destination: "default", Flags: "UG", Gateway: "10.1.0.1", Netif: "eth1"},
+		{Kind: NetRouteKindV6, Table: 10, Destination: "default", Flags: "UG", Gateway: "2001:db8::1", Netif: "eth1"},
+		{Kind: NetRouteKindV4, Table: 20, Destination: "10.20.0.0/16", Flags: "U", Netif: "eth2"},
+		// Routes of an unknown table are only considered part of the main
+		// table.
+		{Kind: NetRouteKindV6, Destination: "default", Flags: "UG", Gateway: "2001:db8::2", Netif: "eth4"},
+	})
+
+	ifaces, err := FindDefaultInterfaces()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"eth0", "eth4"}, ifaces)
+
+	gateways, err := FindDefaultGatewaysInVRF("mgmt")
+	require.NoError(t, err)
+	require.Len(t, gateways, 2)
+	assert.Equal(t, "10.1.0.1", gateways[0].String())
+	assert.Equal(t, "2001:db8::1", gateways[1].String())
+
+	ifaces, err = FindDefaultInterfacesInVRF("blue")
+	require.NoError(t, err)
+	assert.Empty(t, ifaces)
+
+	_, err = FindDefaultRoutesInVRF("red")
+	var notFound *ErrVRFNotFound
+	assert.ErrorAs(t, err, &notFound)
+}